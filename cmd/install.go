@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
@@ -8,8 +9,8 @@ import (
 
 	"github.com/MTVersionManager/mtvm/components/fatalHandler"
 	"github.com/MTVersionManager/mtvm/components/install"
+	"github.com/MTVersionManager/mtvm/plugin/call"
 	"github.com/MTVersionManager/mtvm/shared"
-	"github.com/MTVersionManager/mtvmplugin"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
@@ -22,8 +23,8 @@ type installModel struct {
 	pluginName string
 }
 
-func installInitialModel(plugin mtvmplugin.Plugin, pluginName, version string) installModel {
-	downloadModel := install.New(plugin, pluginName, version)
+func installInitialModel(ctx context.Context, plugin *call.Plugin, pluginName, version string) installModel {
+	downloadModel := install.New(ctx, plugin, pluginName, version)
 	return installModel{
 		installer:  downloadModel,
 		installed:  false,
@@ -37,9 +38,17 @@ func (m installModel) Init() tea.Cmd {
 }
 
 func (m installModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	if msg.(install.InstalledMsg) {
+	switch msg := msg.(type) {
+	case install.InstalledMsg:
 		m.installed = true
 		return m, tea.Quit
+	case install.CanceledMsg:
+		m.installer.Canceled = true
+		return m, tea.Quit
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			return m, m.installer.Cancel()
+		}
 	}
 	var cmd tea.Cmd
 	m.installer, cmd = m.installer.Update(msg)
@@ -47,6 +56,9 @@ func (m installModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m installModel) View() string {
+	if m.installer.Canceled {
+		return "Installation canceled\n"
+	}
 	if !m.installed {
 		return m.installer.View()
 	}
@@ -67,14 +79,15 @@ If you run "mtvm install go latest" it will install the latest version of go`,
 		if err != nil {
 			log.Fatal(err)
 		}
-		plugin, err := shared.LoadPlugin(args[0])
+		loaded, err := shared.LoadPlugin(args[0])
 		if err != nil {
 			log.Fatal(err)
 		}
+		plugin := shared.CallPlugin(loaded)
 		version := args[1]
 		if strings.ToLower(version) == "latest" {
 			var err error
-			version, err = plugin.GetLatestVersion()
+			version, err = plugin.GetLatestVersion(cmd.Context())
 			if err != nil {
 				log.Fatal(err)
 			}
@@ -84,11 +97,14 @@ If you run "mtvm install go latest" it will install the latest version of go`,
 			log.Fatal(err)
 		}
 		if !installed {
-			p := tea.NewProgram(installInitialModel(plugin, args[0], version))
+			p := tea.NewProgram(installInitialModel(cmd.Context(), plugin, args[0], version))
 			if model, err := p.Run(); err != nil {
 				log.Fatal(err)
 			} else if model, ok := model.(installModel); ok {
 				fatalHandler.Handle(model.installer.ErrorHandler)
+				if model.installer.Canceled {
+					os.Exit(1)
+				}
 			} else {
 				log.Fatal("unexpected model type")
 			}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 
 	"github.com/MTVersionManager/mtvm/plugin"
+	"github.com/MTVersionManager/mtvm/plugin/api"
+	"github.com/MTVersionManager/mtvm/plugin/builtin"
 
 	"github.com/MTVersionManager/mtvm/cmd/plugincmds"
 	"github.com/charmbracelet/log"
@@ -15,26 +17,64 @@ import (
 var pluginCmd = &cobra.Command{
 	Use:   "plugin",
 	Short: "Lists the plugins you have installed",
-	Long:  `Lists the plugins you have installed`,
+	Long:  `Lists the plugins you have installed and the plugins built into mtvm`,
+	// plugins.json is migrated to the current schema version before the plugin commands, which are the ones that touch it.
+	// Commands read plugins.json in the old format too, so a failed migration is only worth a warning.
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		_, err := plugin.MigrateEntries(afero.NewOsFs())
+		if err != nil {
+			log.Warn("Failed to migrate plugins.json", "err", err)
+		}
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		entries, err := plugin.GetEntries(afero.NewOsFs())
 		if err != nil {
 			log.Fatal("Error when getting list of installed plugins", "err", err)
 		}
+		installed := make(map[string]bool)
 		if len(entries) == 0 || entries == nil {
 			fmt.Println("No plugins installed")
-			return
 		}
 		for _, entry := range entries {
-			fmt.Printf("%v %v\n", entry.Name, entry.Version)
+			installed[entry.Name] = true
+			fmt.Printf("%v %v%v\n", entry.Name, entry.Version, apiVersionInfo(entry))
+		}
+		// Installed plugins override built-in ones, so those are not listed again
+		for _, name := range builtin.Names() {
+			if !installed[name] {
+				fmt.Printf("%v (built in)\n", name)
+			}
 		}
 	},
 }
 
+// apiVersionInfo describes the API version recorded when the plugin was installed, so plugins don't have to be loaded to list them.
+// It is empty for plugins installed before it was recorded, plugin inspect loads them to find it out.
+func apiVersionInfo(entry plugin.Entry) string {
+	if entry.APIVersion == 0 {
+		return ""
+	}
+	if api.Check(entry.APIVersion) != nil {
+		return fmt.Sprintf(" (API version %v, incompatible)", entry.APIVersion)
+	}
+	return fmt.Sprintf(" (API version %v)", entry.APIVersion)
+}
+
 func init() {
 	rootCmd.AddCommand(pluginCmd)
 	pluginCmd.AddCommand(plugincmds.InstallCmd)
 	pluginCmd.AddCommand(plugincmds.RemoveCmd)
+	pluginCmd.AddCommand(plugincmds.CapabilitiesCmd)
+	pluginCmd.AddCommand(plugincmds.InspectCmd)
+	pluginCmd.AddCommand(plugincmds.TrustCmd)
+	pluginCmd.AddCommand(plugincmds.UpdateCmd)
+	pluginCmd.AddCommand(plugincmds.OutdatedCmd)
+	pluginCmd.AddCommand(plugincmds.SearchCmd)
+	pluginCmd.AddCommand(plugincmds.RollbackCmd)
+	pluginCmd.AddCommand(plugincmds.VerifyCmd)
+	pluginCmd.AddCommand(plugincmds.ExportCmd)
+	pluginCmd.AddCommand(plugincmds.ImportCmd)
+	pluginCmd.AddCommand(plugincmds.InfoCmd)
 	// Here you will define your flags and configuration settings.
 
 	// Cobra supports Persistent Flags which will work for this command
@@ -0,0 +1,45 @@
+package plugincmds
+
+import (
+	"fmt"
+
+	"github.com/MTVersionManager/mtvm/plugin/api"
+	"github.com/MTVersionManager/mtvm/shared"
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+var capabilityDescriptions = map[string]string{
+	api.CapabilityVersionLister: "lists the versions available to install",
+	api.CapabilityEnvProvider:   "provides environment variables for the tool",
+	api.CapabilityVerifier:      "verifies installed versions",
+	api.CapabilityCanceller:     "can cancel a running operation",
+}
+
+// capabilitiesReport returns one line per capability saying whether the plugin supports it
+func capabilitiesReport(loaded any) string {
+	var report string
+	for _, capability := range api.AllCapabilities {
+		mark := shared.CrossMark
+		if api.Supports(loaded, capability) {
+			mark = shared.CheckMark
+		}
+		report += fmt.Sprintf("%v %v: %v\n", mark, capability, capabilityDescriptions[capability])
+	}
+	return report
+}
+
+var CapabilitiesCmd = &cobra.Command{
+	Use:     "capabilities [plugin name]",
+	Short:   "Show the optional capabilities of a plugin",
+	Long:    `Load the plugin with the name specified and show which optional capabilities it supports`,
+	Args:    cobra.ExactArgs(1),
+	Aliases: []string{"caps"},
+	Run: func(cmd *cobra.Command, args []string) {
+		loaded, err := shared.LoadPlugin(args[0])
+		if err != nil {
+			log.Fatal("Error when loading plugin", "err", err)
+		}
+		fmt.Print(capabilitiesReport(loaded))
+	},
+}
@@ -0,0 +1,32 @@
+package plugincmds
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/MTVersionManager/mtvm/plugin/api"
+	"github.com/MTVersionManager/mtvm/shared"
+)
+
+type listerPlugin struct{}
+
+func (listerPlugin) ListVersions() ([]string, error) {
+	return nil, nil
+}
+
+func TestCapabilitiesReport(t *testing.T) {
+	report := capabilitiesReport(listerPlugin{})
+	lines := strings.Split(strings.TrimSuffix(report, "\n"), "\n")
+	if len(lines) != len(api.AllCapabilities) {
+		t.Fatalf("want %v lines, got %v lines in\n%v", len(api.AllCapabilities), len(lines), report)
+	}
+	for i, capability := range api.AllCapabilities {
+		wantMark := shared.CrossMark
+		if capability == api.CapabilityVersionLister {
+			wantMark = shared.CheckMark
+		}
+		if !strings.HasPrefix(lines[i], wantMark+" "+capability) {
+			t.Fatalf("want line %v to start with '%v %v', got '%v'", i, wantMark, capability, lines[i])
+		}
+	}
+}
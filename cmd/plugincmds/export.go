@@ -0,0 +1,39 @@
+package plugincmds
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/MTVersionManager/mtvm/plugin"
+	"github.com/MTVersionManager/mtvm/shared"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+var ExportCmd = &cobra.Command{
+	Use:   "export [file]",
+	Short: "Save the installed plugins to a file",
+	Long: `Write the installed plugins with their versions, metadata urls and constraints to a file,
+or to stdout if no file or - is given. Install them on another system with plugin import.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		entries, err := plugin.GetEntries(afero.NewOsFs())
+		if err != nil {
+			log.Fatal(err)
+		}
+		data, err := plugin.Export(entries)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(args) == 0 || args[0] == "-" {
+			fmt.Println(string(data))
+			return
+		}
+		err = os.WriteFile(args[0], append(data, '\n'), 0o666)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%v Exported %v plugins to %v\n", shared.CheckMark, len(entries), args[0])
+	},
+}
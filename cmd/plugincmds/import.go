@@ -0,0 +1,102 @@
+package plugincmds
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+
+	"github.com/MTVersionManager/mtvm/plugin"
+	"github.com/MTVersionManager/mtvm/shared"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+// entriesToImport returns the imported entries that aren't installed the way they are in the file yet,
+// and the ones that are, which import skips so it can be run again and again
+func entriesToImport(imported, installed []plugin.Entry) ([]plugin.Entry, []plugin.Entry) {
+	var toInstall, skipped []plugin.Entry
+	for _, entry := range imported {
+		if slices.ContainsFunc(installed, func(installedEntry plugin.Entry) bool {
+			return installedEntry.Name == entry.Name &&
+				installedEntry.Version == entry.Version &&
+				installedEntry.MetadataUrl == entry.MetadataUrl &&
+				installedEntry.Constraint == entry.Constraint
+		}) {
+			skipped = append(skipped, entry)
+			continue
+		}
+		toInstall = append(toInstall, entry)
+	}
+	return toInstall, skipped
+}
+
+// readImportFile reads the file to import, which is stdin if the path is -
+func readImportFile(path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(path)
+}
+
+var ImportCmd = &cobra.Command{
+	Use:   "import [file]",
+	Short: "Install the plugins in a file written by plugin export",
+	Long: `Install the plugins in a file written by plugin export, or read from stdin if the file is -,
+at the versions in the file. Plugins that are already installed at those versions are skipped,
+so importing the same file again changes nothing.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		requireChecksum, err := cmd.Flags().GetBool("require-checksum")
+		if err != nil {
+			log.Fatal(err)
+		}
+		data, err := readImportFile(args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+		imported, err := plugin.ParseExport(data)
+		if err != nil {
+			log.Fatal(err)
+		}
+		installed, err := plugin.GetEntries(afero.NewOsFs())
+		if err != nil {
+			log.Fatal(err)
+		}
+		toInstall, skipped := entriesToImport(imported, installed)
+		for _, entry := range skipped {
+			fmt.Printf("%v %v: already installed (%v)\n", shared.CheckMark, entry.Name, entry.Version)
+		}
+		if len(toInstall) == 0 {
+			fmt.Printf("%v installed, %v already installed, %v failed\n", 0, len(skipped), 0)
+			return
+		}
+		p := tea.NewProgram(initialModeModel(toInstall, requireChecksum || shared.Configuration.RequireChecksum, modeImport))
+		model, err := p.Run()
+		if err != nil {
+			log.Fatal(err)
+		}
+		importModel, ok := model.(updateModel)
+		if !ok {
+			log.Fatal("unexpected model type")
+		}
+		if !importModel.done {
+			err = importModel.installer.cleanup()
+			if err != nil {
+				log.Println("Error when cleaning up after the import:", err)
+			}
+			os.Exit(1)
+		}
+		installedCount, alreadyInstalled, failed := importModel.counts()
+		fmt.Printf("%v installed, %v already installed, %v failed\n", installedCount, alreadyInstalled+len(skipped), failed)
+		if failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	ImportCmd.Flags().Bool("require-checksum", false, "refuse to install a plugin without a checksum, also enabled by the requireChecksum setting")
+}
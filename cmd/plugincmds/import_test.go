@@ -0,0 +1,43 @@
+package plugincmds
+
+import (
+	"testing"
+
+	"github.com/MTVersionManager/mtvm/plugin"
+)
+
+func TestEntriesToImport(t *testing.T) {
+	installed := []plugin.Entry{
+		{Name: "current", Version: "1.0.0", MetadataUrl: "https://example.com/current.json"},
+		{Name: "older", Version: "1.0.0", MetadataUrl: "https://example.com/older.json"},
+		{Name: "unpinned", Version: "1.0.0", MetadataUrl: "https://example.com/unpinned.json"},
+	}
+	imported := []plugin.Entry{
+		{Name: "current", Version: "1.0.0", MetadataUrl: "https://example.com/current.json"},
+		{Name: "older", Version: "1.1.0", MetadataUrl: "https://example.com/older.json"},
+		{Name: "unpinned", Version: "1.0.0", MetadataUrl: "https://example.com/unpinned.json", Constraint: "~1.0"},
+		{Name: "missing", Version: "1.0.0", MetadataUrl: "https://example.com/missing.json"},
+	}
+	toInstall, skipped := entriesToImport(imported, installed)
+	if len(skipped) != 1 || skipped[0].Name != "current" {
+		t.Fatalf("want only current to be skipped, got %v", skipped)
+	}
+	if len(toInstall) != 3 {
+		t.Fatalf("want older, unpinned and missing to be installed, got %v", toInstall)
+	}
+}
+
+func TestImportModelInstallsExactVersion(t *testing.T) {
+	model := initialModeModel([]plugin.Entry{
+		{Name: "loremIpsum", Version: "1.2.0", MetadataUrl: "https://example.com/loremIpsum.json", Constraint: "^1"},
+	}, false, modeImport)
+	if model.installer.version != "1.2.0" {
+		t.Fatalf("want installer to install version 1.2.0, got '%v'", model.installer.version)
+	}
+	if model.installer.constraint != "^1" {
+		t.Fatalf("want installer to keep the constraint ^1, got '%v'", model.installer.constraint)
+	}
+	if model.installer.versionConstraint() != "1.2.0" {
+		t.Fatalf("want release to be selected with 1.2.0, got '%v'", model.installer.versionConstraint())
+	}
+}
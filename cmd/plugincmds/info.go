@@ -0,0 +1,255 @@
+package plugincmds
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/MTVersionManager/mtvm/plugin"
+	"github.com/MTVersionManager/mtvm/plugin/index"
+	"github.com/MTVersionManager/mtvm/plugin/manifest"
+	"github.com/MTVersionManager/mtvm/plugin/trust"
+	"github.com/MTVersionManager/mtvm/shared"
+	"github.com/Masterminds/semver/v3"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+// downloadSize returns the size of a download from the Content-Length of a HEAD request, or the size of a local file.
+// It returns -1 if the server doesn't say.
+func downloadSize(client *http.Client, rawUrl string) (int64, error) {
+	parsedUrl, err := url.Parse(rawUrl)
+	if err != nil {
+		return 0, err
+	}
+	if parsedUrl.Scheme == "file" {
+		info, err := os.Stat(shared.FileUrlPath(parsedUrl))
+		if err != nil {
+			return 0, err
+		}
+		return info.Size(), nil
+	}
+	resp, err := client.Head(rawUrl)
+	if err != nil {
+		return 0, err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("%v %v", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+	return resp.ContentLength, nil
+}
+
+// downloadSizes returns the sizes of the downloads, formatted for the info table, requesting them concurrently
+func downloadSizes(client *http.Client, downloads []plugin.Download) []string {
+	sizes := make([]string, len(downloads))
+	var wg sync.WaitGroup
+	for i, download := range downloads {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			size, err := downloadSize(client, download.Url)
+			switch {
+			case err != nil:
+				sizes[i] = "error: " + err.Error()
+			case size < 0:
+				sizes[i] = "unknown"
+			default:
+				sizes[i] = formatSize(size)
+			}
+		}()
+	}
+	wg.Wait()
+	return sizes
+}
+
+// formatSize formats a size in bytes with a binary unit, like 1.5 MiB
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%v B", size)
+	}
+	value := float64(size) / unit
+	for _, suffix := range []string{"KiB", "MiB", "GiB"} {
+		if value < unit || suffix == "GiB" {
+			return fmt.Sprintf("%.1f %v", value, suffix)
+		}
+		value /= unit
+	}
+	return ""
+}
+
+// installAction describes what installing the available version would do given the installed version, which is empty if the plugin isn't installed.
+// Without a constraint, install doesn't replace a newer version.
+func installAction(installed, available string, constraint string) (string, error) {
+	if installed == "" {
+		return "not installed, installing would install " + available, nil
+	}
+	installedVersion, err := semver.NewVersion(installed)
+	if err != nil {
+		return "", err
+	}
+	availableVersion, err := semver.NewVersion(available)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case availableVersion.Equal(installedVersion):
+		return fmt.Sprintf("%v, installing would change nothing", installed), nil
+	case availableVersion.GreaterThan(installedVersion):
+		return fmt.Sprintf("%v, installing would upgrade it to %v", installed, available), nil
+	case constraint != "":
+		return fmt.Sprintf("%v, installing would downgrade it to %v", installed, available), nil
+	default:
+		return fmt.Sprintf("%v, installing would change nothing because it is newer than %v, add @%v to downgrade", installed, available, available), nil
+	}
+}
+
+// printMetadataInfo writes what the metadata offers for the release matching the constraint to w
+func printMetadataInfo(w io.Writer, client *http.Client, metadata plugin.Metadata, constraint string, installed string) error {
+	releases := metadata.AllReleases()
+	versions := make([]string, len(releases))
+	for i, release := range releases {
+		versions[i] = release.Version
+	}
+	selected, selectErr := metadata.Select(constraint, shared.Version)
+	fmt.Fprintf(w, "Name: %v\n", metadata.Name)
+	if selectErr != nil {
+		fmt.Fprintf(w, "%v Version: %v\n", shared.CrossMark, selectErr)
+	} else {
+		fmt.Fprintf(w, "Version: %v\n", selected.Version)
+	}
+	if len(versions) > 1 {
+		fmt.Fprintf(w, "Releases: %v\n", strings.Join(versions, ", "))
+	}
+	if metadata.PublicKey != "" {
+		fmt.Fprintf(w, "Signed: yes, key ID %v\n", trust.Key{PublicKey: metadata.PublicKey}.ID())
+	} else {
+		fmt.Fprintln(w, "Signed: no")
+	}
+	if selectErr != nil {
+		fmt.Fprintf(w, "Installed: %v\n", orNotInstalled(installed))
+		return nil
+	}
+	action, err := installAction(installed, selected.Version, constraint)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Installed: %v\n\n", action)
+
+	choice := plugin.SelectDownload(selected.Downloads, host())
+	sizes := downloadSizes(client, selected.Downloads)
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "PLATFORM\tTYPE\tCHECKSUM\tSIZE\tYOUR SYSTEM")
+	for i, download := range selected.Downloads {
+		pluginType := download.Type
+		if pluginType == "" {
+			pluginType = shared.PluginTypeNative
+		}
+		checksum := "none"
+		if algorithm, _, ok := strings.Cut(download.Checksum, ":"); ok {
+			checksum = algorithm
+		}
+		chosen := ""
+		if choice.Found && download.Url == choice.Download.Url {
+			chosen = shared.CheckMark
+		}
+		fmt.Fprintf(table, "%v\t%v\t%v\t%v\t%v\n", download, pluginType, checksum, sizes[i], chosen)
+	}
+	err = table.Flush()
+	if err != nil {
+		return err
+	}
+	if !choice.Found {
+		fmt.Fprintf(w, "\n%v\n", choice)
+	}
+	return nil
+}
+
+// printManifestInfo writes what a manifest offers to w
+func printManifestInfo(w io.Writer, pluginManifest manifest.Manifest, constraint string, installed string) error {
+	fmt.Fprintf(w, "Name: %v\n", pluginManifest.Name)
+	fmt.Fprintf(w, "Version: %v\n", pluginManifest.Version)
+	fmt.Fprintln(w, "Type: manifest, works on every system")
+	action, err := installAction(installed, pluginManifest.Version, constraint)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Installed: %v\n", action)
+	return nil
+}
+
+func orNotInstalled(installed string) string {
+	if installed == "" {
+		return "not installed"
+	}
+	return installed
+}
+
+var InfoCmd = &cobra.Command{
+	Use:   "info [plugin url or name][@version]",
+	Short: "Show what a plugin's metadata offers before installing it",
+	Long: `Load the metadata of a plugin like plugin install does, without installing it, and show its name,
+the version that would be installed, the systems it has downloads for with their checksums and sizes,
+and what installing it would do to the installed version.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		urlOrName, constraint := splitConstraint(args[0])
+		metadataUrl, err := resolveMetadataUrl(urlOrName)
+		if errors.Is(err, index.ErrNoIndexes) {
+			fmt.Println("Please enter a valid http url or path to a file, or configure pluginIndexes to look up plugins by name")
+			os.Exit(1)
+		}
+		if errors.Is(err, index.ErrNotInIndex) {
+			fmt.Printf("There is no plugin named %v in the configured plugin indexes\n", urlOrName)
+			os.Exit(1)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		loaded, err := fetchMetadata(http.DefaultClient, metadataUrl)
+		if err != nil {
+			log.Fatal(err)
+		}
+		switch loaded := loaded.(type) {
+		case plugin.Metadata:
+			metadata, err := loaded.ResolveUrls(metadataUrl)
+			if err != nil {
+				log.Fatal(err)
+			}
+			installed, err := installedVersion(metadata.Name)
+			if err != nil {
+				log.Fatal(err)
+			}
+			err = printMetadataInfo(os.Stdout, http.DefaultClient, metadata, constraint, installed)
+			if err != nil {
+				log.Fatal(err)
+			}
+		case manifest.Manifest:
+			installed, err := installedVersion(loaded.Name)
+			if err != nil {
+				log.Fatal(err)
+			}
+			err = printManifestInfo(os.Stdout, loaded, constraint, installed)
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+	},
+}
+
+// installedVersion returns the installed version of a plugin, or an empty string if it isn't installed
+func installedVersion(pluginName string) (string, error) {
+	version, err := plugin.InstalledVersion(pluginName, afero.NewOsFs())
+	if errors.Is(err, plugin.ErrNotFound) {
+		return "", nil
+	}
+	return version, err
+}
@@ -0,0 +1,94 @@
+package plugincmds
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/MTVersionManager/mtvm/plugin"
+	"github.com/MTVersionManager/mtvm/shared"
+)
+
+func TestInstallAction(t *testing.T) {
+	tests := map[string]struct {
+		installed  string
+		available  string
+		constraint string
+		want       string
+	}{
+		"not installed":            {available: "1.0.0", want: "not installed"},
+		"same version":             {installed: "1.0.0", available: "1.0.0", want: "change nothing"},
+		"upgrade":                  {installed: "1.0.0", available: "1.1.0", want: "upgrade it to 1.1.0"},
+		"downgrade":                {installed: "1.1.0", available: "1.0.0", constraint: "1.0.0", want: "downgrade it to 1.0.0"},
+		"older without constraint": {installed: "1.1.0", available: "1.0.0", want: "add @1.0.0 to downgrade"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			action, err := installAction(tt.installed, tt.available, tt.constraint)
+			if err != nil {
+				t.Fatalf("want no error, got %v", err)
+			}
+			if !strings.Contains(action, tt.want) {
+				t.Fatalf("want action containing '%v', got '%v'", tt.want, action)
+			}
+		})
+	}
+}
+
+func TestPrintMetadataInfo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			t.Errorf("want only HEAD requests, got %v", r.Method)
+		}
+		switch r.URL.Path {
+		case "/native":
+			w.Header().Set("Content-Length", "2048")
+		case "/wasm":
+			w.Header().Set("Content-Length", "10")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	metadata := plugin.Metadata{
+		Name:    "loremIpsum",
+		Version: "1.1.0",
+		Downloads: []plugin.Download{
+			{OS: runtime.GOOS, Arch: runtime.GOARCH, Url: server.URL + "/native", Checksum: "sha256:" + strings.Repeat("ab", 32)},
+			{OS: plugin.AnyPlatform, Arch: plugin.AnyPlatform, Url: server.URL + "/wasm", Type: "wasm"},
+			{OS: "loremIpsum", Arch: "amd64", Url: server.URL + "/missing"},
+		},
+		Releases: []plugin.Release{{Version: "1.0.0", Downloads: []plugin.Download{{OS: plugin.AnyPlatform, Arch: plugin.AnyPlatform, Url: server.URL + "/wasm"}}}},
+	}
+	var info bytes.Buffer
+	err := printMetadataInfo(&info, server.Client(), metadata, "", "1.0.0")
+	if err != nil {
+		t.Fatalf("want no error, got %v", err)
+	}
+	for _, want := range []string{
+		"Version: 1.1.0",
+		"Releases: 1.1.0, 1.0.0",
+		"Signed: no",
+		"installing would upgrade it to 1.1.0",
+		"2.0 KiB",
+		"10 B",
+		"sha256",
+		"error: 404 Not Found",
+	} {
+		if !strings.Contains(info.String(), want) {
+			t.Fatalf("want info to contain '%v', got\n%v", want, info.String())
+		}
+	}
+	for _, line := range strings.Split(info.String(), "\n") {
+		chosen := strings.Contains(line, shared.CheckMark)
+		if strings.Contains(line, "2.0 KiB") && !chosen {
+			t.Fatalf("want the native download to be marked as the one for your system, got line %v", line)
+		}
+		if strings.Contains(line, "10 B") && chosen {
+			t.Fatalf("want only the native download to be marked, got line %v", line)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package plugincmds
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/MTVersionManager/mtvm/plugin"
+	"github.com/MTVersionManager/mtvm/plugin/api"
+	"github.com/MTVersionManager/mtvm/plugin/builtin"
+	"github.com/MTVersionManager/mtvm/shared"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+// Kinds of problems inspectPlugin can find
+const (
+	problemNone         = ""
+	problemMissingFile  = "missing file"
+	problemLoadFailure  = "load failure"
+	problemRuntimeError = "runtime error"
+)
+
+// inspectPlugin goes through the steps of using a plugin and writes what it finds out at each of them to w.
+// It stops at the first step the plugin fails at and returns the kind of problem, or problemNone if there are no problems.
+func inspectPlugin(ctx context.Context, pluginName string, fs afero.Fs, w io.Writer) string {
+	entries, err := plugin.GetEntries(fs)
+	if err != nil {
+		fmt.Fprintf(w, "%v Entry: failed to read plugins.json: %v\n", shared.CrossMark, err)
+	} else if i := slices.IndexFunc(entries, func(entry plugin.Entry) bool { return entry.Name == pluginName }); i != -1 {
+		fmt.Fprintf(w, "%v Entry: version %v from %v\n", shared.CheckMark, entries[i].Version, entries[i].MetadataUrl)
+	} else {
+		fmt.Fprintf(w, "%v Entry: not in plugins.json\n", shared.CrossMark)
+	}
+
+	pluginType, err := shared.InstalledPluginType(pluginName)
+	if errors.Is(err, shared.ErrPluginNotFound) {
+		if !slices.Contains(builtin.Names(), pluginName) {
+			fmt.Fprintf(w, "%v File: no plugin file in %v\n", shared.CrossMark, shared.Configuration.PluginDir)
+			return problemMissingFile
+		}
+		fmt.Fprintf(w, "%v File: none, the plugin is built into mtvm\n", shared.CheckMark)
+	} else if err != nil {
+		fmt.Fprintf(w, "%v File: %v\n", shared.CrossMark, err)
+		return problemMissingFile
+	} else {
+		pluginFile := shared.PluginFile(pluginName, pluginType)
+		info, err := os.Stat(pluginFile)
+		if err != nil {
+			fmt.Fprintf(w, "%v File: %v\n", shared.CrossMark, err)
+			return problemMissingFile
+		}
+		fmt.Fprintf(w, "%v File: %v (%v plugin, %v bytes)\n", shared.CheckMark, pluginFile, pluginType, info.Size())
+	}
+
+	loaded, err := shared.LoadPlugin(pluginName)
+	if err != nil {
+		fmt.Fprintf(w, "%v Load: %v\n", shared.CrossMark, err)
+		return problemLoadFailure
+	}
+	fmt.Fprintf(w, "%v Load: loaded with API version %v\n", shared.CheckMark, api.VersionOf(loaded))
+	fmt.Fprintln(w, "Capabilities:")
+	for _, line := range strings.SplitAfter(capabilitiesReport(loaded), "\n") {
+		if line != "" {
+			fmt.Fprint(w, "  "+line)
+		}
+	}
+
+	problem := problemNone
+	called := shared.CallPlugin(loaded)
+	latest, err := called.GetLatestVersion(ctx)
+	if err != nil {
+		fmt.Fprintf(w, "%v GetLatestVersion: %v\n", shared.CrossMark, err)
+		problem = problemRuntimeError
+	} else {
+		fmt.Fprintf(w, "%v GetLatestVersion: %v\n", shared.CheckMark, latest)
+	}
+	current, err := called.GetCurrentVersion(ctx, filepath.Join(shared.Configuration.InstallDir, pluginName), shared.Configuration.PathDir)
+	if err != nil {
+		fmt.Fprintf(w, "%v GetCurrentVersion: %v\n", shared.CrossMark, err)
+		problem = problemRuntimeError
+	} else if current == "" {
+		fmt.Fprintf(w, "%v GetCurrentVersion: no version in use\n", shared.CheckMark)
+	} else {
+		fmt.Fprintf(w, "%v GetCurrentVersion: %v\n", shared.CheckMark, current)
+	}
+	return problem
+}
+
+var InspectCmd = &cobra.Command{
+	Use:   "inspect [plugin name]",
+	Short: "Diagnose a plugin",
+	Long: `Load the plugin with the name specified and show what mtvm knows about it:
+its entry in plugins.json, its file, whether it loads, its API version and capabilities,
+and the results of asking it for the latest and current version.
+Exits with a non-zero status if there is a missing file, a load failure or a runtime error.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		problem := inspectPlugin(cmd.Context(), args[0], afero.NewOsFs(), os.Stdout)
+		if problem != problemNone {
+			fmt.Printf("\nProblem: %v\n", problem)
+			os.Exit(1)
+		}
+		fmt.Println("\nNo problems found")
+	},
+}
@@ -0,0 +1,94 @@
+package plugincmds
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/MTVersionManager/mtvm/shared"
+	"github.com/spf13/afero"
+)
+
+// writeTestManifest installs a manifest plugin that gets its latest version from latestUrl
+func writeTestManifest(t *testing.T, pluginName, latestUrl string) {
+	shared.Configuration.PluginDir = t.TempDir()
+	shared.Configuration.InstallDir = t.TempDir()
+	shared.Configuration.PathDir = t.TempDir()
+	err := os.WriteFile(shared.PluginFile(pluginName, shared.PluginTypeManifest), []byte(`{
+	"type": "manifest",
+	"name": "`+pluginName+`",
+	"version": "0.0.0",
+	"url": "https://example.com/{{version}}.tar.gz",
+	"archive": "tar.gz",
+	"binaries": ["bin/loremIpsum"],
+	"latest": {"url": "`+latestUrl+`", "format": "text"}
+}`), 0o666)
+	if err != nil {
+		t.Fatalf("want no error when writing manifest, got %v", err)
+	}
+}
+
+func TestInspectPlugin(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("1.2.3"))
+	}))
+	defer server.Close()
+	writeTestManifest(t, "loremIpsumInspect", server.URL)
+	var report bytes.Buffer
+	problem := inspectPlugin(context.Background(), "loremIpsumInspect", afero.NewMemMapFs(), &report)
+	if problem != problemNone {
+		t.Fatalf("want no problem, got %v in report\n%v", problem, report.String())
+	}
+	if !strings.Contains(report.String(), "GetLatestVersion: 1.2.3") {
+		t.Fatalf("want report to contain the latest version, got\n%v", report.String())
+	}
+}
+
+func TestInspectPluginProblems(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	tests := map[string]struct {
+		setup func(t *testing.T, pluginName string)
+		want  string
+	}{
+		"missing file": {
+			setup: func(t *testing.T, pluginName string) {
+				shared.Configuration.PluginDir = t.TempDir()
+			},
+			want: problemMissingFile,
+		},
+		"load failure": {
+			setup: func(t *testing.T, pluginName string) {
+				shared.Configuration.PluginDir = t.TempDir()
+				err := os.WriteFile(shared.PluginFile(pluginName, shared.PluginTypeNative), []byte("not a plugin"), 0o666)
+				if err != nil {
+					t.Fatalf("want no error when writing plugin file, got %v", err)
+				}
+			},
+			want: problemLoadFailure,
+		},
+		"runtime error": {
+			setup: func(t *testing.T, pluginName string) {
+				writeTestManifest(t, pluginName, server.URL)
+			},
+			want: problemRuntimeError,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			// Loaded plugins are cached by name, so every case needs its own
+			pluginName := "loremIpsum" + strings.ReplaceAll(name, " ", "")
+			tt.setup(t, pluginName)
+			var report bytes.Buffer
+			if got := inspectPlugin(context.Background(), pluginName, afero.NewMemMapFs(), &report); got != tt.want {
+				t.Fatalf("want problem %v, got '%v' in report\n%v", tt.want, got, report.String())
+			}
+		})
+	}
+}
@@ -1,18 +1,26 @@
 package plugincmds
 
-// TODO: Make it create the plugin directory
 import (
+	"cmp"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"net/http"
+	"net/url"
 	"os"
-	"path/filepath"
-	"runtime"
+	"slices"
+	"strings"
+	"sync"
 
 	"github.com/MTVersionManager/mtvm/components/fatalHandler"
 
 	"github.com/MTVersionManager/mtvm/components/downloader"
 	"github.com/MTVersionManager/mtvm/plugin"
+	"github.com/MTVersionManager/mtvm/plugin/index"
+	"github.com/MTVersionManager/mtvm/plugin/manifest"
+	"github.com/MTVersionManager/mtvm/plugin/trust"
 	"github.com/MTVersionManager/mtvm/shared"
 	"github.com/Masterminds/semver/v3"
 	tea "github.com/charmbracelet/bubbletea"
@@ -31,22 +39,79 @@ type installModel struct {
 	noDownload       bool
 	done             bool
 	fileSystem       afero.Fs
+	requireChecksum  bool
+	metadata         plugin.Metadata
+	// constraint is the version or semver constraint the installed version has to match, empty for the newest version
+	constraint string
+	// force installs the plugin even if the version is already installed
+	force bool
+	// version is the exact version to install, it is used instead of the constraint, which is still stored in the entry
+	version string
+	// askingTrust is true while the user is asked whether to trust the key the metadata is signed with
+	askingTrust bool
+	// onFinish is run instead of quitting when the model is part of another program
+	onFinish tea.Cmd
+	// metadataMirrors are the mirrors of the metadata, stored in the entry
+	metadataMirrors []string
+	// metadataMirror is the url the metadata was loaded from
+	metadataMirror string
+	// mirror is the url the plugin file was downloaded from, or the one it was downloaded from last time until it is downloaded
+	mirror string
+	// checkPlugin loads the installed plugin file to check that this version of mtvm can use it and returns its API version, it is shared.CheckPluginFile
+	checkPlugin func(pluginName, pluginType string) (int, error)
+	// committed is true once the plugin file was moved into place
+	committed bool
+	canceled  bool
 }
 
+// signatureMsg contains the detached signature of the plugin metadata
+type signatureMsg []byte
+
+// metadataTrustedMsg is sent once the metadata passed the trust checks
+type metadataTrustedMsg struct{}
+
+// untrustedKeyMsg is sent if the user has to be asked whether to trust the key the metadata is signed with
+type untrustedKeyMsg struct{}
+
+var ErrChecksumRequired = errors.New("the plugin metadata has no checksum for your system, but checksums are required")
+
 type pluginDownloadInfo struct {
-	Url     string
+	Url string
+	// Mirrors are tried in order if Url fails
+	Mirrors []string
 	Name    string
+	Type    string
 	Version *semver.Version
+	// Checksum is the expected checksum of the downloaded file, like sha256:<hex digest>
+	Checksum string
+	// Data is the content of the plugin file if it doesn't have to be downloaded
+	Data []byte
+	// Choice explains why the download was chosen, or why there is none
+	Choice plugin.DownloadChoice
 }
 
-func initialInstallModel(url string) installModel {
+// initialInstallModel creates a model installing the plugin from the metadata at url.
+// If requireChecksum is true, metadata without a checksum for the download is rejected.
+func initialInstallModel(url string, requireChecksum bool) installModel {
 	return installModel{
-		downloader:  downloader.New(url, downloader.UseTitle("Downloading plugin metadata...")),
-		metadataUrl: url,
-		fileSystem:  afero.NewOsFs(),
+		downloader:      downloader.New(url, downloader.UseTitle("Downloading plugin metadata...")),
+		metadataUrl:     url,
+		fileSystem:      afero.NewOsFs(),
+		requireChecksum: requireChecksum,
+		checkPlugin:     shared.CheckPluginFile,
 	}
 }
 
+// useMirrors makes the model fall back to the mirrors of the entry if its metadata url fails,
+// trying the mirrors that worked last time first
+func (m installModel) useMirrors(entry plugin.Entry) installModel {
+	urls := entry.MetadataUrls()
+	m.downloader = downloader.New(urls[0], downloader.UseTitle("Downloading plugin metadata..."), downloader.Mirrors(urls[1:]...))
+	m.metadataMirrors = entry.MetadataMirrors
+	m.mirror = entry.Mirror
+	return m
+}
+
 func loadMetadata(rawData []byte) (plugin.Metadata, error) {
 	var metadata plugin.Metadata
 	err := json.Unmarshal(rawData, &metadata)
@@ -61,8 +126,16 @@ func loadMetadata(rawData []byte) (plugin.Metadata, error) {
 	return metadata, nil
 }
 
+// loadMetadataCmd returns a manifest.Manifest if the data is a manifest and a plugin.Metadata otherwise
 func loadMetadataCmd(rawData []byte) tea.Cmd {
 	return func() tea.Msg {
+		if manifest.IsManifest(rawData) {
+			pluginManifest, err := manifest.Parse(rawData)
+			if err != nil {
+				return err
+			}
+			return pluginManifest
+		}
 		metadata, err := loadMetadata(rawData)
 		if err != nil {
 			return err
@@ -71,24 +144,301 @@ func loadMetadataCmd(rawData []byte) tea.Cmd {
 	}
 }
 
-func getPluginInfoCmd(metadata plugin.Metadata) tea.Cmd {
+// readUrl reads what is at a http(s) or file url, returning an error if it is larger than limit
+func readUrl(client *http.Client, rawUrl string, limit int64) ([]byte, error) {
+	parsedUrl, err := url.Parse(rawUrl)
+	if err != nil {
+		return nil, err
+	}
+	var body io.ReadCloser
+	if parsedUrl.Scheme == "file" {
+		body, err = os.Open(shared.FileUrlPath(parsedUrl))
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		resp, err := client.Get(rawUrl)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			_ = resp.Body.Close()
+			return nil, fmt.Errorf("%v %v", resp.StatusCode, http.StatusText(resp.StatusCode))
+		}
+		body = resp.Body
+	}
+	defer body.Close()
+	data, err := io.ReadAll(io.LimitReader(body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%v is larger than %v bytes", rawUrl, limit)
+	}
+	return data, nil
+}
+
+// readFirstUrl reads the first of the urls that can be read with readUrl, returning which one it is.
+// The urls after the first are mirrors, which are only tried if reading the ones before them failed.
+func readFirstUrl(client *http.Client, urls []string, limit int64) ([]byte, string, error) {
+	var errs []error
+	for _, rawUrl := range urls {
+		data, err := readUrl(client, rawUrl, limit)
+		if err == nil {
+			return data, rawUrl, nil
+		}
+		if len(urls) == 1 {
+			return nil, "", err
+		}
+		errs = append(errs, fmt.Errorf("%v: %w", rawUrl, err))
+	}
+	return nil, "", fmt.Errorf("%w: %w", downloader.ErrAllMirrorsFailed, errors.Join(errs...))
+}
+
+// fetchSignatureCmd reads the signature served next to the metadata
+func fetchSignatureCmd(metadataUrl string) tea.Cmd {
+	return func() tea.Msg {
+		// Signatures are short, anything longer is not a signature
+		signature, err := readUrl(http.DefaultClient, metadataUrl+".sig", 4096)
+		if err != nil {
+			return fmt.Errorf("failed to read the signature of the plugin metadata: %w", err)
+		}
+		return signatureMsg(signature)
+	}
+}
+
+// checkTrustCmd verifies the signature of signed metadata and checks its key against the trust store.
+// It returns a metadataTrustedMsg if the metadata can be used, an untrustedKeyMsg if the user has to decide, and an error otherwise.
+func checkTrustCmd(metadata plugin.Metadata, rawData, signature []byte, fs afero.Fs) tea.Cmd {
+	return func() tea.Msg {
+		if metadata.PublicKey != "" {
+			err := trust.Verify(metadata.PublicKey, rawData, signature)
+			if err != nil {
+				return err
+			}
+		}
+		keys, err := trust.Keys(fs)
+		if err != nil {
+			return err
+		}
+		err = trust.Check(keys, metadata.Name, metadata.PublicKey)
+		if errors.Is(err, trust.ErrUntrustedKey) {
+			return untrustedKeyMsg{}
+		}
+		if err != nil {
+			return err
+		}
+		return metadataTrustedMsg{}
+	}
+}
+
+// trustKeyCmd trusts the key the metadata is signed with
+func trustKeyCmd(metadata plugin.Metadata, fs afero.Fs) tea.Cmd {
 	return func() tea.Msg {
+		err := trust.Add(trust.Key{Name: metadata.Name, PublicKey: metadata.PublicKey}, fs)
+		if err != nil {
+			return err
+		}
+		return metadataTrustedMsg{}
+	}
+}
+
+// host is the system downloads are chosen for, it is only detected once
+var host = sync.OnceValue(plugin.DetectHost)
+
+// platformDownload returns the download that suits this system best, the bool is false if there is none
+func platformDownload(metadata plugin.Metadata) (plugin.Download, bool) {
+	choice := plugin.SelectDownload(metadata.Downloads, host())
+	return choice.Download, choice.Found
+}
+
+// getPluginInfoCmd picks the newest release matching the constraint and the download for this system from the metadata.
+// If requireChecksum is true, it returns ErrChecksumRequired if the download has no checksum.
+func getPluginInfoCmd(metadata plugin.Metadata, constraint string, requireChecksum bool) tea.Cmd {
+	return func() tea.Msg {
+		metadata, err := metadata.Select(constraint, shared.Version)
+		if err != nil {
+			return err
+		}
 		version, err := semver.NewVersion(metadata.Version)
 		if err != nil {
 			return err
 		}
-		var url string
-		for _, v := range metadata.Downloads {
-			if v.OS == runtime.GOOS && v.Arch == runtime.GOARCH {
-				url = v.Url
+		choice := plugin.SelectDownload(metadata.Downloads, host())
+		download := choice.Download
+		// The checksum is checked now, because the plugin file is created before the download starts.
+		// The signature only covers the metadata, so a signed plugin is only protected if its download has a checksum.
+		if download.Checksum == "" && download.Url != "" && metadata.PublicKey != "" {
+			return fmt.Errorf("%w: the metadata is signed, so the download has to have a checksum", ErrChecksumRequired)
+		} else if download.Checksum == "" && download.Url != "" && requireChecksum {
+			return ErrChecksumRequired
+		} else if download.Checksum != "" {
+			_, _, err = downloader.ParseChecksum(download.Checksum)
+			if err != nil {
+				return err
 			}
 		}
 		return pluginDownloadInfo{
-			Url:     url,
-			Name:    metadata.Name,
+			Url:      download.Url,
+			Mirrors:  download.Mirrors,
+			Name:     metadata.Name,
+			Type:     download.Type,
+			Version:  version,
+			Checksum: download.Checksum,
+			Choice:   choice,
+		}
+	}
+}
+
+// pluginDownloader returns a downloader that downloads the plugin to its temporary file, which is moved to where shared.LoadPlugin looks for it once it is complete
+func (m installModel) pluginDownloader() downloader.Model {
+	opts := []downloader.Option{
+		downloader.WriteToFs(plugin.TempFile(m.pluginInfo.Name, m.pluginInfo.Type), m.fileSystem),
+		downloader.UseTitle("Downloading plugin..."),
+	}
+	if m.pluginInfo.Checksum != "" {
+		opts = append(opts, downloader.VerifyChecksum(m.pluginInfo.Checksum))
+	}
+	urls := plugin.PreferMirror(append([]string{m.pluginInfo.Url}, m.pluginInfo.Mirrors...), m.mirror)
+	if len(urls) > 1 {
+		opts = append(opts, downloader.Mirrors(urls[1:]...))
+	}
+	return downloader.New(urls[0], opts...)
+}
+
+// getManifestInfoCmd returns a pluginDownloadInfo that installs the manifest itself as the plugin file.
+// A manifest only describes one version, so it returns an error wrapping plugin.ErrNoMatchingRelease if that version doesn't match the constraint.
+// Manifests can't be signed, so it returns an error wrapping trust.ErrUnsigned if a key is trusted for the plugin.
+func getManifestInfoCmd(pluginManifest manifest.Manifest, rawData []byte, constraint string, fs afero.Fs) tea.Cmd {
+	return func() tea.Msg {
+		keys, err := trust.Keys(fs)
+		if err != nil {
+			return err
+		}
+		err = trust.Check(keys, pluginManifest.Name, "")
+		if err != nil {
+			return err
+		}
+		version, err := semver.NewVersion(pluginManifest.Version)
+		if err != nil {
+			return err
+		}
+		if constraint != "" {
+			constraints, err := semver.NewConstraint(constraint)
+			if err != nil {
+				return err
+			}
+			if !constraints.Check(version) {
+				return fmt.Errorf("%w: the manifest of the %v plugin is for version %v, which doesn't match %v", plugin.ErrNoMatchingRelease, pluginManifest.Name, version, constraint)
+			}
+		}
+		return pluginDownloadInfo{
+			Name:    pluginManifest.Name,
+			Type:    shared.PluginTypeManifest,
 			Version: version,
+			Data:    rawData,
+		}
+	}
+}
+
+// startPluginInstall moves on to putting the plugin file into the plugin directory
+func (m installModel) startPluginInstall() (installModel, tea.Cmd) {
+	m.step++
+	if m.pluginInfo.Data != nil {
+		return m, plugin.WriteFileCmd(m.pluginInfo.Name, m.pluginInfo.Type, m.pluginInfo.Data, m.fileSystem)
+	}
+	m.downloader = m.pluginDownloader()
+	return m, m.downloader.Init()
+}
+
+// finish ends the installation, quitting the program unless the model is part of another one
+func (m installModel) finish() tea.Cmd {
+	if m.onFinish != nil {
+		return m.onFinish
+	}
+	return tea.Quit
+}
+
+// cleanup undoes what an installation that didn't finish did, so the entry and the file on disk stay in sync.
+// If the file was already moved into place, the build installed before is restored without keeping the new one as a backup.
+func (m installModel) cleanup() error {
+	if m.done || m.pluginInfo.Name == "" {
+		return nil
+	}
+	if m.committed {
+		_, err := plugin.Restore(m.pluginInfo.Name, m.fileSystem)
+		if errors.Is(err, plugin.ErrNoBackup) {
+			return plugin.Remove(m.pluginInfo.Name, m.fileSystem)
 		}
+		return err
 	}
+	return plugin.RemoveTempFile(m.pluginInfo.Name, m.pluginInfo.Type, m.fileSystem)
+}
+
+// entry returns the entry of the plugin being installed.
+// Which mirrors worked is only recorded if there are mirrors to choose from.
+func (m installModel) entry() plugin.Entry {
+	entry := plugin.Entry{
+		Name:            m.pluginInfo.Name,
+		Version:         m.pluginInfo.Version.String(),
+		MetadataUrl:     m.metadataUrl,
+		Constraint:      m.constraint,
+		MetadataMirrors: m.metadataMirrors,
+	}
+	if len(m.metadataMirrors) > 0 {
+		entry.MetadataMirror = m.metadataMirror
+	}
+	if len(m.pluginInfo.Mirrors) > 0 {
+		entry.Mirror = m.mirror
+	}
+	return entry
+}
+
+// servedByMirror reports whether the plugin file was downloaded from a mirror rather than the url of the download
+func (m installModel) servedByMirror() bool {
+	return m.mirror != m.pluginInfo.Url && slices.Contains(m.pluginInfo.Mirrors, m.mirror)
+}
+
+// updateEntryCmd adds the entry of the plugin to plugins.json, with the checksum of the installed plugin file so plugin verify can check it.
+// The plugin file is loaded first to record its API version, and plugins this version of mtvm can't use are refused,
+// so cleanup restores the build installed before instead of the entry being updated.
+func (m installModel) updateEntryCmd() tea.Cmd {
+	entry := m.entry()
+	fs := m.fileSystem
+	checkPlugin := m.checkPlugin
+	return func() tea.Msg {
+		types, err := plugin.InstalledTypes(entry.Name, fs)
+		if err != nil {
+			return err
+		}
+		if len(types) > 0 {
+			entry.APIVersion, err = checkPlugin(entry.Name, types[0])
+			if err != nil {
+				return err
+			}
+			entry.Checksum, err = plugin.FileChecksum(entry.Name, types[0], fs)
+			if err != nil {
+				return err
+			}
+		}
+		return plugin.UpdateEntriesCmd(entry, fs)()
+	}
+}
+
+// errorCmd sends err as a message, so the update model can record it for the plugin being installed and carry on,
+// while a single installation still ends in the error case of Update
+func errorCmd(err error) tea.Cmd {
+	return func() tea.Msg {
+		return err
+	}
+}
+
+// versionConstraint returns the constraint the release to install is selected with
+func (m installModel) versionConstraint() string {
+	if m.version != "" {
+		return m.version
+	}
+	return m.constraint
 }
 
 func (m installModel) Init() tea.Cmd {
@@ -102,7 +452,21 @@ func (m installModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case error:
 		m.errorHandler, cmd = m.errorHandler.Update(msg)
 		cmds = append(cmds, cmd)
+	case downloader.DownloadCanceledMsg:
+		m.canceled = true
+		return m, m.finish()
 	case tea.KeyMsg:
+		if m.askingTrust {
+			switch msg.String() {
+			case "y", "Y":
+				m.askingTrust = false
+				return m, trustKeyCmd(m.metadata, m.fileSystem)
+			case "n", "N", "enter", "ctrl+c", "q":
+				m.askingTrust = false
+				return m, errorCmd(trust.ErrUntrustedKey)
+			}
+			return m, nil
+		}
 		switch msg.String() {
 		case "ctrl+c", "q":
 			return m, m.downloader.StopDownload()
@@ -113,53 +477,75 @@ func (m installModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.step == 0 {
 				cmds = append(cmds, loadMetadataCmd(m.downloader.GetDownloadedData()))
 			} else {
-				cmds = append(cmds, plugin.UpdateEntriesCmd(plugin.Entry{
-					Name:        m.pluginInfo.Name,
-					Version:     m.pluginInfo.Version.String(),
-					MetadataUrl: m.metadataUrl,
-				}, m.fileSystem))
+				m.mirror = m.downloader.ServedBy()
+				cmds = append(cmds, plugin.CommitFileCmd(m.pluginInfo.Name, m.pluginInfo.Type, m.fileSystem))
 			}
+		case "WriteFile":
+			cmds = append(cmds, plugin.CommitFileCmd(m.pluginInfo.Name, m.pluginInfo.Type, m.fileSystem))
+		case "CommitFile":
+			m.committed = true
+			cmds = append(cmds, m.updateEntryCmd())
 		case "UpdateEntries":
-			m.done = true
-			return m, tea.Quit
+			// The entry is also updated when the version is already installed, to store the constraint
+			m.done = !m.versionInstalled
+			return m, m.finish()
 		}
 	case plugin.Metadata:
-		cmds = append(cmds, getPluginInfoCmd(msg))
-	case pluginDownloadInfo:
-		m.pluginInfo = msg
-		forceFlagUsed, err := InstallCmd.Flags().GetBool("force")
+		// Relative urls and the signature are loaded from the mirror the metadata came from
+		m.metadataMirror = cmp.Or(m.downloader.ServedBy(), m.metadataUrl)
+		metadata, err := msg.ResolveUrls(m.metadataMirror)
 		if err != nil {
-			m.errorHandler, cmd = m.errorHandler.Update(err)
-			cmds = append(cmds, cmd)
+			return m, errorCmd(err)
+		}
+		m.metadata = metadata
+		if len(metadata.Mirrors) > 0 {
+			m.metadataMirrors = slices.DeleteFunc(slices.Clone(metadata.Mirrors), func(mirror string) bool { return mirror == m.metadataUrl })
+		}
+		if msg.PublicKey == "" {
+			cmds = append(cmds, checkTrustCmd(metadata, nil, nil, m.fileSystem))
+		} else {
+			cmds = append(cmds, fetchSignatureCmd(m.metadataMirror))
 		}
-		if m.pluginInfo.Url == "" {
+	case signatureMsg:
+		cmds = append(cmds, checkTrustCmd(m.metadata, m.downloader.GetDownloadedData(), msg, m.fileSystem))
+	case untrustedKeyMsg:
+		m.askingTrust = true
+	case metadataTrustedMsg:
+		cmds = append(cmds, getPluginInfoCmd(m.metadata, m.versionConstraint(), m.requireChecksum))
+	case manifest.Manifest:
+		m.metadataMirror = cmp.Or(m.downloader.ServedBy(), m.metadataUrl)
+		cmds = append(cmds, getManifestInfoCmd(msg, m.downloader.GetDownloadedData(), m.versionConstraint(), m.fileSystem))
+	case pluginDownloadInfo:
+		m.pluginInfo = msg
+		if m.pluginInfo.Url == "" && m.pluginInfo.Data == nil {
 			m.noDownload = true
-			return m, tea.Quit
+			return m, m.finish()
 		}
-		if forceFlagUsed {
-			m.step++
-			m.downloader = downloader.New(m.pluginInfo.Url, downloader.WriteToFs(filepath.Join(shared.Configuration.PluginDir, m.pluginInfo.Name+"."+shared.LibraryExtension), m.fileSystem), downloader.UseTitle("Downloading plugin..."))
-			cmds = append(cmds, m.downloader.Init())
+		if m.force {
+			m, cmd = m.startPluginInstall()
+			cmds = append(cmds, cmd)
 		} else {
 			cmds = append(cmds, plugin.InstalledVersionCmd(msg.Name, m.fileSystem))
 		}
 	case plugin.VersionMsg:
-		constraint, err := semver.NewConstraint("> " + string(msg))
+		installedVersion, err := semver.NewVersion(string(msg))
 		if err != nil {
-			m.errorHandler, cmd = m.errorHandler.Update(err)
-			cmds = append(cmds, cmd)
+			return m, errorCmd(err)
 		}
-		if !constraint.Check(m.pluginInfo.Version) {
+		// With a constraint, the version matching it is installed even if it is older than the installed one
+		if m.versionConstraint() != "" && m.pluginInfo.Version.Equal(installedVersion) {
 			m.versionInstalled = true
-			return m, tea.Quit
+			return m, m.updateEntryCmd()
 		}
-		m.step++
-		m.downloader = downloader.New(m.pluginInfo.Url, downloader.WriteToFs(filepath.Join(shared.Configuration.PluginDir, m.pluginInfo.Name+".so"), m.fileSystem), downloader.UseTitle("Downloading plugin..."))
-		cmds = append(cmds, m.downloader.Init())
+		if m.versionConstraint() == "" && !m.pluginInfo.Version.GreaterThan(installedVersion) {
+			m.versionInstalled = true
+			return m, m.finish()
+		}
+		m, cmd = m.startPluginInstall()
+		cmds = append(cmds, cmd)
 	case plugin.NotFoundMsg:
-		m.step++
-		m.downloader = downloader.New(m.pluginInfo.Url, downloader.WriteToFs(filepath.Join(shared.Configuration.PluginDir, m.pluginInfo.Name+".so"), m.fileSystem), downloader.UseTitle("Downloading plugin..."))
-		cmds = append(cmds, m.downloader.Init())
+		m, cmd = m.startPluginInstall()
+		cmds = append(cmds, cmd)
 	}
 	m.downloader, cmd = m.downloader.Update(msg)
 	cmds = append(cmds, cmd)
@@ -167,36 +553,128 @@ func (m installModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m installModel) View() string {
+	if m.canceled {
+		return "Installation canceled\n"
+	}
+	if m.askingTrust {
+		return fmt.Sprintf("The metadata of the %v plugin is signed with a key you haven't trusted before:\n%v (ID %v)\nTrust this key? [y/N] ", m.metadata.Name, m.metadata.PublicKey, trust.Key{PublicKey: m.metadata.PublicKey}.ID())
+	}
 	if m.done {
-		return fmt.Sprintf("%v Successfully installed version %v of the %v plugin\n", shared.CheckMark, m.pluginInfo.Version, m.pluginInfo.Name)
+		view := fmt.Sprintf("%v Successfully installed version %v of the %v plugin\n", shared.CheckMark, m.pluginInfo.Version, m.pluginInfo.Name)
+		if m.servedByMirror() {
+			view += fmt.Sprintf("Downloaded from the mirror %v\n", m.mirror)
+		}
+		// The choice is only worth explaining if there were other downloads for this system to choose from
+		if m.pluginInfo.Choice.HasAlternatives() {
+			view += m.pluginInfo.Choice.String() + "\n"
+		}
+		return view
+	}
+	if m.versionInstalled && m.versionConstraint() != "" {
+		return fmt.Sprintf("Version %v of the %v plugin, the newest matching %v, is already installed.\nUse the --force or -f flag to reinstall it.\n", m.pluginInfo.Version, m.pluginInfo.Name, m.versionConstraint())
 	}
 	if m.versionInstalled {
 		return fmt.Sprintf("You already have the latest version of the %v plugin installed.\nUse the --force or -f flag to reinstall it.\n", m.pluginInfo.Name)
 	}
 	if m.noDownload {
-		return fmt.Sprintf("Sadly, the %v plugin does not provide a download for your system.\n", m.pluginInfo.Name)
+		return fmt.Sprintf("Sadly, the %v plugin does not provide a download for your system.\n%v\n", m.pluginInfo.Name, m.pluginInfo.Choice)
 	}
 	return m.downloader.View() + "\n"
 }
 
+// splitConstraint splits an argument like loremIpsum@^1.2 into the plugin url or name and the version constraint.
+// The constraint is empty if there is none, so urls containing @ and local files with @ in their name still work.
+func splitConstraint(arg string) (string, string) {
+	i := strings.LastIndex(arg, "@")
+	if i == -1 {
+		return arg, ""
+	}
+	if _, err := os.Stat(arg); err == nil {
+		return arg, ""
+	}
+	if _, err := semver.NewConstraint(arg[i+1:]); err != nil {
+		return arg, ""
+	}
+	return arg[:i], arg[i+1:]
+}
+
+// resolveMetadataUrl returns the argument of the install command if it is a http(s) or file url,
+// the file url of it if it is a local file, and otherwise looks it up in the configured plugin indexes
+func resolveMetadataUrl(urlOrName string) (string, error) {
+	if isMetadataUrl(urlOrName) {
+		return urlOrName, nil
+	}
+	if info, err := os.Stat(urlOrName); err == nil && !info.IsDir() {
+		return shared.FileUrl(urlOrName)
+	}
+	metadataUrl, err := index.Resolve(http.DefaultClient, shared.Configuration.PluginIndexes, urlOrName)
+	if err != nil {
+		return "", err
+	}
+	if !isMetadataUrl(metadataUrl) {
+		return "", fmt.Errorf("the index entry of %v is not a valid http or file url: %v", urlOrName, metadataUrl)
+	}
+	return metadataUrl, nil
+}
+
+// isMetadataUrl reports whether metadata can be loaded from rawUrl
+func isMetadataUrl(rawUrl string) bool {
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	if validate.Var(rawUrl, "http_url") == nil {
+		return true
+	}
+	parsedUrl, err := url.Parse(rawUrl)
+	return err == nil && parsedUrl.Scheme == "file" && parsedUrl.Path != ""
+}
+
 var InstallCmd = &cobra.Command{
-	Use:     "install [plugin url]",
-	Short:   "Install a plugin",
-	Long:    `Install a plugin given a link to the plugin's metadata JSON`,
+	Use:   "install [plugin url or name][@version]",
+	Short: "Install a plugin",
+	Long: `Install a plugin given a link or path to the plugin's metadata JSON or to a plugin manifest,
+or the name of a plugin in one of the plugin indexes configured with pluginIndexes.
+Downloads in metadata read from a file can be paths relative to it, which makes installing without internet possible.
+Add @ and a version or semver constraint, like loremIpsum@1.2.3 or loremIpsum@^1.2, to install the newest release matching it.
+The constraint is remembered, so plugin update only installs releases matching it.
+If a download fails, the mirrors listed for it in the metadata are tried in order, and the one that worked is tried first next time.`,
 	Args:    cobra.ExactArgs(1),
 	Aliases: []string{"i", "in"},
 	Run: func(cmd *cobra.Command, args []string) {
-		validate := validator.New(validator.WithRequiredStructEnabled())
-		err := validate.Var(args[0], "http_url")
-		if err != nil {
-			fmt.Println("Please enter a valid http url")
+		urlOrName, constraint := splitConstraint(args[0])
+		metadataUrl, err := resolveMetadataUrl(urlOrName)
+		if errors.Is(err, index.ErrNoIndexes) {
+			fmt.Println("Please enter a valid http url or path to a file, or configure pluginIndexes to install plugins by name")
 			os.Exit(1)
 		}
-		p := tea.NewProgram(initialInstallModel(args[0]))
+		if errors.Is(err, index.ErrNotInIndex) {
+			fmt.Printf("There is no plugin named %v in the configured plugin indexes\n", urlOrName)
+			os.Exit(1)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		requireChecksum, err := cmd.Flags().GetBool("require-checksum")
+		if err != nil {
+			log.Fatal(err)
+		}
+		force, err := cmd.Flags().GetBool("force")
+		if err != nil {
+			log.Fatal(err)
+		}
+		installer := initialInstallModel(metadataUrl, requireChecksum || shared.Configuration.RequireChecksum)
+		installer.constraint = constraint
+		installer.force = force
+		p := tea.NewProgram(installer)
 		if model, err := p.Run(); err != nil {
 			fmt.Printf("Alas, there's been an error: %v", err)
 		} else if model, ok := model.(installModel); ok {
+			err = model.cleanup()
+			if err != nil {
+				log.Println("Error when cleaning up after the installation:", err)
+			}
 			fatalHandler.Handle(model.errorHandler)
+			if model.canceled {
+				os.Exit(1)
+			}
 		} else {
 			log.Fatal("unexpected model type")
 		}
@@ -205,4 +683,5 @@ var InstallCmd = &cobra.Command{
 
 func init() {
 	InstallCmd.Flags().BoolP("force", "f", false, "force install a plugin")
+	InstallCmd.Flags().Bool("require-checksum", false, "refuse to install a plugin without a checksum, also enabled by the requireChecksum setting")
 }
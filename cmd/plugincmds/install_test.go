@@ -2,16 +2,27 @@ package plugincmds
 
 import (
 	"context"
+	"crypto/ed25519"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/MTVersionManager/mtvm/components/downloader"
 	"github.com/MTVersionManager/mtvm/plugin"
+	"github.com/MTVersionManager/mtvm/plugin/api"
+	"github.com/MTVersionManager/mtvm/plugin/manifest"
+	"github.com/MTVersionManager/mtvm/plugin/trust"
 	"github.com/MTVersionManager/mtvm/shared"
 	"github.com/Masterminds/semver/v3"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/afero"
 )
 
 func TestGetPluginInfo(t *testing.T) {
@@ -25,7 +36,7 @@ func TestGetPluginInfo(t *testing.T) {
 				Url:  "https://example.com",
 			},
 		},
-	})()
+	}, "", false)()
 	if downloadInfo, ok := msg.(pluginDownloadInfo); ok {
 		if downloadInfo.Name != "loremIpsum" {
 			t.Fatalf("want name to be 'loremIpsum', got name '%v'", downloadInfo.Name)
@@ -55,7 +66,7 @@ func TestGetPluginInfoInvalidVersion(t *testing.T) {
 				Url:  "https://example.com",
 			},
 		},
-	})()
+	}, "", false)()
 	if err, ok := msg.(error); !ok {
 		t.Fatalf("want error, got %T with contents %v", msg, msg)
 	} else if !errors.Is(err, semver.ErrInvalidSemVer) {
@@ -83,7 +94,7 @@ func TestPluginInstallUpdateCancelCtrlC(t *testing.T) {
 }
 
 func CancelTest(keyPress tea.KeyMsg) error {
-	model := initialInstallModel("https://example.com")
+	model := initialInstallModel("https://example.com", false)
 	_, cancel := context.WithCancel(context.Background())
 	modelUpdated, _ := model.Update(downloader.DownloadStartedMsg{
 		Cancel: cancel,
@@ -100,7 +111,7 @@ func CancelTest(keyPress tea.KeyMsg) error {
 }
 
 func TestPluginInstallUpdateEntriesSuccess(t *testing.T) {
-	model := initialInstallModel("https://example.com")
+	model := initialInstallModel("https://example.com", false)
 	_, cmd := model.Update(shared.SuccessMsg("UpdateEntries"))
 	if cmd == nil {
 		t.Fatal("want not nil command, got nil")
@@ -110,3 +121,469 @@ func TestPluginInstallUpdateEntriesSuccess(t *testing.T) {
 		t.Fatalf("want command to return tea.QuitMsg, returned %T with content %v", msg, msg)
 	}
 }
+
+func TestLoadMetadataManifest(t *testing.T) {
+	msg := loadMetadataCmd([]byte(`{
+	"type": "manifest",
+	"name": "loremIpsum",
+	"version": "1.0.0",
+	"url": "https://example.com/{{version}}/loremIpsum-{{os}}-{{arch}}.tar.gz",
+	"archive": "tar.gz",
+	"binaries": ["loremIpsum"],
+	"versions": ["1.0.0"]
+}`))()
+	pluginManifest, ok := msg.(manifest.Manifest)
+	if !ok {
+		t.Fatalf("want manifest.Manifest returned, got %T with content %v", msg, msg)
+	}
+	if pluginManifest.Name != "loremIpsum" {
+		t.Fatalf("want name to be 'loremIpsum', got name '%v'", pluginManifest.Name)
+	}
+}
+
+func TestGetManifestInfo(t *testing.T) {
+	rawData := []byte("manifest")
+	msg := getManifestInfoCmd(manifest.Manifest{
+		Name:    "loremIpsum",
+		Version: "1.0.0",
+	}, rawData, "", afero.NewMemMapFs())()
+	downloadInfo, ok := msg.(pluginDownloadInfo)
+	if !ok {
+		t.Fatalf("want pluginDownloadInfo returned, got %T with content %v", msg, msg)
+	}
+	if downloadInfo.Type != shared.PluginTypeManifest {
+		t.Fatalf("want type to be '%v', got type '%v'", shared.PluginTypeManifest, downloadInfo.Type)
+	}
+	if string(downloadInfo.Data) != string(rawData) {
+		t.Fatalf("want data to be the manifest, got %v", string(downloadInfo.Data))
+	}
+}
+
+func TestGetManifestInfoSignedBefore(t *testing.T) {
+	publicKey, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := afero.NewMemMapFs()
+	err = trust.Add(trust.Key{Name: "loremIpsum", PublicKey: base64.StdEncoding.EncodeToString(publicKey)}, fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := getManifestInfoCmd(manifest.Manifest{Name: "loremIpsum", Version: "1.0.0"}, []byte("manifest"), "", fs)()
+	if err, ok := msg.(error); !ok || !errors.Is(err, trust.ErrUnsigned) {
+		t.Fatalf("want trust.ErrUnsigned for a manifest of a plugin that was signed before, got %T with content %v", msg, msg)
+	}
+}
+
+func TestGetPluginInfoPrefersExactPlatform(t *testing.T) {
+	tests := map[string]struct {
+		downloads []plugin.Download
+		wantUrl   string
+		wantType  string
+	}{
+		"any only": {
+			downloads: []plugin.Download{
+				{OS: plugin.AnyPlatform, Arch: plugin.AnyPlatform, Url: "https://example.com/any.wasm", Type: shared.PluginTypeWasm},
+			},
+			wantUrl:  "https://example.com/any.wasm",
+			wantType: shared.PluginTypeWasm,
+		},
+		"exact before any": {
+			downloads: []plugin.Download{
+				{OS: runtime.GOOS, Arch: runtime.GOARCH, Url: "https://example.com/native"},
+				{OS: plugin.AnyPlatform, Arch: plugin.AnyPlatform, Url: "https://example.com/any.wasm", Type: shared.PluginTypeWasm},
+			},
+			wantUrl:  "https://example.com/native",
+			wantType: "",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			msg := getPluginInfoCmd(plugin.Metadata{
+				Name:      "loremIpsum",
+				Version:   "0.0.0",
+				Downloads: tt.downloads,
+			}, "", false)()
+			downloadInfo, ok := msg.(pluginDownloadInfo)
+			if !ok {
+				t.Fatalf("want pluginDownloadInfo returned, got %T with content %v", msg, msg)
+			}
+			if downloadInfo.Url != tt.wantUrl {
+				t.Fatalf("want url to be '%v', got url '%v'", tt.wantUrl, downloadInfo.Url)
+			}
+			if downloadInfo.Type != tt.wantType {
+				t.Fatalf("want type to be '%v', got type '%v'", tt.wantType, downloadInfo.Type)
+			}
+		})
+	}
+}
+
+func TestGetPluginInfoRequireChecksum(t *testing.T) {
+	metadata := plugin.Metadata{
+		Name:    "loremIpsum",
+		Version: "0.0.0",
+		Downloads: []plugin.Download{
+			{
+				OS:   runtime.GOOS,
+				Arch: runtime.GOARCH,
+				Url:  "https://example.com",
+			},
+		},
+	}
+	msg := getPluginInfoCmd(metadata, "", true)()
+	if err, ok := msg.(error); !ok || !errors.Is(err, ErrChecksumRequired) {
+		t.Fatalf("want ErrChecksumRequired, got %T with content %v", msg, msg)
+	}
+	metadata.Downloads[0].Checksum = "sha256:" + strings.Repeat("ab", 32)
+	msg = getPluginInfoCmd(metadata, "", true)()
+	if downloadInfo, ok := msg.(pluginDownloadInfo); !ok || downloadInfo.Checksum != metadata.Downloads[0].Checksum {
+		t.Fatalf("want pluginDownloadInfo with the checksum, got %T with content %v", msg, msg)
+	}
+}
+
+func TestGetPluginInfoSignedRequiresChecksum(t *testing.T) {
+	metadata := plugin.Metadata{
+		Name:      "loremIpsum",
+		Version:   "0.0.0",
+		PublicKey: base64.StdEncoding.EncodeToString(make([]byte, ed25519.PublicKeySize)),
+		Downloads: []plugin.Download{
+			{
+				OS:   runtime.GOOS,
+				Arch: runtime.GOARCH,
+				Url:  "https://example.com",
+			},
+		},
+	}
+	msg := getPluginInfoCmd(metadata, "", false)()
+	if err, ok := msg.(error); !ok || !errors.Is(err, ErrChecksumRequired) {
+		t.Fatalf("want ErrChecksumRequired, got %T with content %v", msg, msg)
+	}
+	metadata.Downloads[0].Checksum = "sha256:" + strings.Repeat("ab", 32)
+	msg = getPluginInfoCmd(metadata, "", false)()
+	if _, ok := msg.(pluginDownloadInfo); !ok {
+		t.Fatalf("want pluginDownloadInfo, got %T with content %v", msg, msg)
+	}
+}
+
+func TestCheckTrust(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	metadata := plugin.Metadata{Name: "loremIpsum", PublicKey: base64.StdEncoding.EncodeToString(publicKey)}
+	rawData := []byte(`{"name": "loremIpsum"}`)
+	signature := []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, rawData)))
+	fs := afero.NewMemMapFs()
+
+	msg := checkTrustCmd(metadata, []byte(`{"name": "dolorSitAmet"}`), signature, fs)()
+	if err, ok := msg.(error); !ok || !errors.Is(err, trust.ErrInvalidSignature) {
+		t.Fatalf("want trust.ErrInvalidSignature for tampered metadata, got %T with content %v", msg, msg)
+	}
+	msg = checkTrustCmd(metadata, rawData, signature, fs)()
+	if _, ok := msg.(untrustedKeyMsg); !ok {
+		t.Fatalf("want untrustedKeyMsg for a new key, got %T with content %v", msg, msg)
+	}
+	msg = trustKeyCmd(metadata, fs)()
+	if _, ok := msg.(metadataTrustedMsg); !ok {
+		t.Fatalf("want metadataTrustedMsg after trusting the key, got %T with content %v", msg, msg)
+	}
+	msg = checkTrustCmd(metadata, rawData, signature, fs)()
+	if _, ok := msg.(metadataTrustedMsg); !ok {
+		t.Fatalf("want metadataTrustedMsg for a trusted key, got %T with content %v", msg, msg)
+	}
+	msg = checkTrustCmd(plugin.Metadata{Name: "loremIpsum"}, rawData, nil, fs)()
+	if err, ok := msg.(error); !ok || !errors.Is(err, trust.ErrUnsigned) {
+		t.Fatalf("want trust.ErrUnsigned for unsigned metadata of a plugin that was signed before, got %T with content %v", msg, msg)
+	}
+}
+
+func TestPluginInstallRejectKey(t *testing.T) {
+	model := initialInstallModel("https://example.com", false)
+	updated, _ := model.Update(untrustedKeyMsg{})
+	if !strings.Contains(updated.View(), "Trust this key?") {
+		t.Fatalf("want prompt to trust the key, got view %v", updated.View())
+	}
+	updated, cmd := updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	if cmd == nil {
+		t.Fatal("want not nil command, got nil")
+	}
+	msg := cmd()
+	if err, ok := msg.(error); !ok || !errors.Is(err, trust.ErrUntrustedKey) {
+		t.Fatalf("want trust.ErrUntrustedKey after rejecting the key, got %T with content %v", msg, msg)
+	}
+	_, cmd = updated.Update(msg)
+	if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Fatal("want command to quit after the error")
+	}
+}
+
+func TestResolveMetadataUrl(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"plugins": {"loremIpsum": {"metadataUrl": "plugins/loremIpsum.json"}}}`))
+	}))
+	defer server.Close()
+	shared.Configuration.PluginIndexes = []string{server.URL + "/index.json"}
+	got, err := resolveMetadataUrl("loremIpsum")
+	if err != nil {
+		t.Fatalf("want no error, got %v", err)
+	}
+	if got != server.URL+"/plugins/loremIpsum.json" {
+		t.Fatalf("want %v/plugins/loremIpsum.json, got %v", server.URL, got)
+	}
+	got, err = resolveMetadataUrl("https://example.com/loremIpsum.json")
+	if err != nil || got != "https://example.com/loremIpsum.json" {
+		t.Fatalf("want urls to be used as they are, got %v with error %v", got, err)
+	}
+}
+
+func TestResolveMetadataUrlLocalFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "loremIpsum.json")
+	if err := os.WriteFile(path, []byte("{}"), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+	got, err := resolveMetadataUrl("./loremIpsum.json")
+	if err != nil {
+		t.Fatalf("want no error, got %v", err)
+	}
+	want, err := shared.FileUrl(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Fatalf("want %v, got %v", want, got)
+	}
+}
+
+func TestSplitConstraint(t *testing.T) {
+	tests := map[string]struct {
+		arg            string
+		wantUrlOrName  string
+		wantConstraint string
+	}{
+		"name":              {arg: "loremIpsum", wantUrlOrName: "loremIpsum"},
+		"name and exact":    {arg: "loremIpsum@1.2.3", wantUrlOrName: "loremIpsum", wantConstraint: "1.2.3"},
+		"url and caret":     {arg: "https://example.com/loremIpsum.json@^1.2", wantUrlOrName: "https://example.com/loremIpsum.json", wantConstraint: "^1.2"},
+		"url with userinfo": {arg: "https://user@example.com/loremIpsum.json", wantUrlOrName: "https://user@example.com/loremIpsum.json"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			urlOrName, constraint := splitConstraint(tt.arg)
+			if urlOrName != tt.wantUrlOrName || constraint != tt.wantConstraint {
+				t.Fatalf("want %v and %v, got %v and %v", tt.wantUrlOrName, tt.wantConstraint, urlOrName, constraint)
+			}
+		})
+	}
+}
+
+func TestLoadMetadataReleases(t *testing.T) {
+	metadata, err := loadMetadata([]byte(`{
+	"name": "loremIpsum",
+	"releases": [
+		{"version": "1.0.0", "downloads": [{"os": "any", "arch": "any", "url": "1.0.0/loremIpsum.wasm", "type": "wasm"}]}
+	]
+}`))
+	if err != nil {
+		t.Fatalf("want metadata with only releases to be valid, got %v", err)
+	}
+	if len(metadata.Releases) != 1 {
+		t.Fatalf("want 1 release, got %v", metadata.Releases)
+	}
+	_, err = loadMetadata([]byte(`{"name": "loremIpsum"}`))
+	if err == nil {
+		t.Fatal("want error for metadata without version and releases, got nil")
+	}
+}
+
+func TestGetPluginInfoConstraint(t *testing.T) {
+	metadata := plugin.Metadata{
+		Name:      "loremIpsum",
+		Version:   "2.0.0",
+		Downloads: []plugin.Download{{OS: runtime.GOOS, Arch: runtime.GOARCH, Url: "https://example.com/2.0.0"}},
+		Releases: []plugin.Release{
+			{Version: "1.1.0", Downloads: []plugin.Download{{OS: runtime.GOOS, Arch: runtime.GOARCH, Url: "https://example.com/1.1.0"}}},
+		},
+	}
+	msg := getPluginInfoCmd(metadata, "~1", false)()
+	downloadInfo, ok := msg.(pluginDownloadInfo)
+	if !ok {
+		t.Fatalf("want pluginDownloadInfo returned, got %T with content %v", msg, msg)
+	}
+	if downloadInfo.Version.String() != "1.1.0" || downloadInfo.Url != "https://example.com/1.1.0" {
+		t.Fatalf("want version 1.1.0 from https://example.com/1.1.0, got version %v from %v", downloadInfo.Version, downloadInfo.Url)
+	}
+	msg = getPluginInfoCmd(metadata, "3.0.0", false)()
+	if err, ok := msg.(error); !ok || !errors.Is(err, plugin.ErrNoMatchingRelease) {
+		t.Fatalf("want plugin.ErrNoMatchingRelease, got %T with content %v", msg, msg)
+	}
+}
+
+func TestPluginInstallPinsInstalledVersion(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	model := initialInstallModel("https://example.com", false)
+	model.fileSystem = fs
+	model.constraint = "^1.0"
+	model.pluginInfo = pluginDownloadInfo{Name: "loremIpsum", Version: semver.MustParse("1.1.0")}
+	updated, cmd := model.Update(plugin.VersionMsg("1.1.0"))
+	if cmd == nil {
+		t.Fatal("want not nil command, got nil")
+	}
+	if msg := cmd(); msg != shared.SuccessMsg("UpdateEntries") {
+		t.Fatalf("want the entry to be updated, got %T with content %v", msg, msg)
+	}
+	updated, _ = updated.Update(shared.SuccessMsg("UpdateEntries"))
+	if !strings.Contains(updated.View(), "already installed") {
+		t.Fatalf("want view saying the version is already installed, got %v", updated.View())
+	}
+	entries, err := plugin.GetEntries(fs)
+	if err != nil {
+		t.Fatalf("want no error when getting entries, got %v", err)
+	}
+	if len(entries) != 1 || entries[0].Constraint != "^1.0" {
+		t.Fatalf("want entry pinned to ^1.0, got %v", entries)
+	}
+}
+
+func TestPluginDownloaderPrefersLastMirror(t *testing.T) {
+	model := initialInstallModel("https://example.com/loremIpsum.json", false)
+	model.fileSystem = afero.NewMemMapFs()
+	model.pluginInfo = pluginDownloadInfo{
+		Url:     "https://example.com/1.1.0/loremIpsum.so",
+		Mirrors: []string{"https://mirror.example.org/1.1.0/loremIpsum.so", "https://other.example.org/1.1.0/loremIpsum.so"},
+		Name:    "loremIpsum",
+		Version: semver.MustParse("1.1.0"),
+	}
+	if got := model.pluginDownloader().GetUrl(); got != model.pluginInfo.Url {
+		t.Fatalf("want the url of the download to be tried first, got %v", got)
+	}
+	model.mirror = "https://other.example.org/1.0.0/loremIpsum.so"
+	if got := model.pluginDownloader().GetUrl(); got != model.pluginInfo.Mirrors[1] {
+		t.Fatalf("want the mirror that worked last time to be tried first, got %v", got)
+	}
+}
+
+func TestInstallEntryRecordsMirrors(t *testing.T) {
+	entry := plugin.Entry{
+		Name:            "loremIpsum",
+		Version:         "1.0.0",
+		MetadataUrl:     "https://example.com/loremIpsum.json",
+		MetadataMirrors: []string{"https://mirror.example.org/loremIpsum.json"},
+		MetadataMirror:  "https://mirror.example.org/loremIpsum.json",
+		Mirror:          "https://mirror.example.org/1.0.0/loremIpsum.so",
+	}
+	model := initialInstallModel(entry.MetadataUrl, false).useMirrors(entry)
+	if got := model.downloader.GetUrl(); got != entry.MetadataMirror {
+		t.Fatalf("want the metadata mirror that worked last time to be tried first, got %v", got)
+	}
+	model.metadataMirror = entry.MetadataMirror
+	model.pluginInfo = pluginDownloadInfo{Name: "loremIpsum", Version: semver.MustParse("1.1.0")}
+	if got := model.entry(); got.Mirror != "" || got.MetadataMirror != entry.MetadataMirror {
+		t.Fatalf("want only the metadata mirror to be recorded for a download without mirrors, got %+v", got)
+	}
+	model.pluginInfo.Url = "https://example.com/1.1.0/loremIpsum.so"
+	model.pluginInfo.Mirrors = []string{"https://mirror.example.org/1.1.0/loremIpsum.so"}
+	model.mirror = model.pluginInfo.Mirrors[0]
+	if got := model.entry(); got.Mirror != model.pluginInfo.Mirrors[0] {
+		t.Fatalf("want the mirror the plugin was downloaded from to be recorded, got %+v", got)
+	}
+	model.done = true
+	if !strings.Contains(model.View(), "Downloaded from the mirror https://mirror.example.org/1.1.0/loremIpsum.so") {
+		t.Fatalf("want view naming the mirror, got %v", model.View())
+	}
+}
+
+func TestPluginInstallRefusesIncompatibleApi(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	model := initialInstallModel("https://example.com", false)
+	model.fileSystem = fs
+	model.pluginInfo = pluginDownloadInfo{Name: "loremIpsum", Type: shared.PluginTypeWasm, Version: semver.MustParse("1.0.0")}
+	model.checkPlugin = func(pluginName, pluginType string) (int, error) {
+		if pluginType != shared.PluginTypeWasm {
+			t.Fatalf("want the wasm plugin file to be checked, got type %v", pluginType)
+		}
+		return api.Version + 1, fmt.Errorf("%v: %w", pluginName, &api.IncompatibleError{Version: api.Version + 1})
+	}
+	err := afero.WriteFile(fs, shared.PluginFile("loremIpsum", shared.PluginTypeWasm), []byte("wasm"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	updated, cmd := model.Update(shared.SuccessMsg("CommitFile"))
+	if cmd == nil {
+		t.Fatal("want not nil command, got nil")
+	}
+	msg := cmd()
+	if err, ok := msg.(error); !ok || !errors.Is(err, api.ErrIncompatible) {
+		t.Fatalf("want api.ErrIncompatible, got %T with content %v", msg, msg)
+	}
+	updated, _ = updated.Update(msg)
+	if err := updated.(installModel).cleanup(); err != nil {
+		t.Fatalf("want no error when cleaning up, got %v", err)
+	}
+	types, err := plugin.InstalledTypes("loremIpsum", fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(types) > 0 {
+		t.Fatalf("want the incompatible plugin file to be removed, got types %v", types)
+	}
+	entries, err := plugin.GetEntries(fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) > 0 {
+		t.Fatalf("want no entry for the incompatible plugin, got %v", entries)
+	}
+}
+
+func TestPluginInstallRecordsApiVersion(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	model := initialInstallModel("https://example.com", false)
+	model.fileSystem = fs
+	model.pluginInfo = pluginDownloadInfo{Name: "loremIpsum", Type: shared.PluginTypeWasm, Version: semver.MustParse("1.0.0")}
+	model.checkPlugin = func(pluginName, pluginType string) (int, error) {
+		return api.Version, nil
+	}
+	err := afero.WriteFile(fs, shared.PluginFile("loremIpsum", shared.PluginTypeWasm), []byte("wasm"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, cmd := model.Update(shared.SuccessMsg("CommitFile"))
+	if msg := cmd(); msg != shared.SuccessMsg("UpdateEntries") {
+		t.Fatalf("want the entry to be updated, got %T with content %v", msg, msg)
+	}
+	entries, err := plugin.GetEntries(fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].APIVersion != api.Version {
+		t.Fatalf("want entry with API version %v, got %+v", api.Version, entries)
+	}
+}
+
+func TestPluginInstallFromAnotherUrl(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	err := afero.WriteFile(fs, shared.PluginFile("loremIpsum", shared.PluginTypeWasm), []byte("wasm"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, metadataUrl := range []string{"https://example.com/loremIpsum.json", "https://example.org/loremIpsum.json"} {
+		model := initialInstallModel(metadataUrl, false)
+		model.fileSystem = fs
+		model.pluginInfo = pluginDownloadInfo{Name: "loremIpsum", Type: shared.PluginTypeWasm, Version: semver.MustParse("1.0.0")}
+		model.checkPlugin = func(pluginName, pluginType string) (int, error) {
+			return api.Version, nil
+		}
+		_, cmd := model.Update(shared.SuccessMsg("CommitFile"))
+		if msg := cmd(); msg != shared.SuccessMsg("UpdateEntries") {
+			t.Fatalf("want the entry to be updated, got %T with content %v", msg, msg)
+		}
+	}
+	entries, err := plugin.GetEntries(fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].MetadataUrl != "https://example.org/loremIpsum.json" {
+		t.Fatalf("want one entry with the second metadata url, got %+v", entries)
+	}
+}
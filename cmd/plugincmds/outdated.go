@@ -0,0 +1,168 @@
+package plugincmds
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"runtime"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/MTVersionManager/mtvm/plugin"
+	"github.com/MTVersionManager/mtvm/plugin/manifest"
+	"github.com/MTVersionManager/mtvm/shared"
+	"github.com/Masterminds/semver/v3"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+// ExitCodeOutdated is the exit code of the outdated command if a plugin is outdated,
+// it is different from the exit code for errors so scripts can tell them apart
+const ExitCodeOutdated = 2
+
+// outdatedStatus is what the outdated command found out about a plugin
+type outdatedStatus struct {
+	entry     plugin.Entry
+	available string
+	outdated  bool
+	// unpublished is true if the metadata has no download for this system anymore
+	unpublished bool
+	err         error
+}
+
+func (s outdatedStatus) String() string {
+	switch {
+	case s.err != nil:
+		return fmt.Sprintf("error: %v", s.err)
+	case s.unpublished:
+		return fmt.Sprintf("no longer published for %v/%v", runtime.GOOS, runtime.GOARCH)
+	case s.outdated:
+		return "outdated"
+	default:
+		return "up to date"
+	}
+}
+
+// maxMetadataSize is the size metadata can't be larger than, which is far more than real metadata needs
+const maxMetadataSize = 10 << 20
+
+// fetchMetadata reads and parses the metadata at the first of the urls that can be read, which is a plugin.Metadata or a manifest.Manifest.
+// The urls after the first are mirrors of the metadata.
+func fetchMetadata(client *http.Client, urls ...string) (any, error) {
+	rawData, _, err := readFirstUrl(client, urls, maxMetadataSize)
+	if err != nil {
+		return nil, err
+	}
+	msg := loadMetadataCmd(rawData)()
+	if err, ok := msg.(error); ok {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// checkOutdated compares the installed version of a plugin with the version in its metadata
+func checkOutdated(client *http.Client, entry plugin.Entry) outdatedStatus {
+	status := outdatedStatus{entry: entry}
+	metadata, err := fetchMetadata(client, entry.MetadataUrls()...)
+	if err != nil {
+		status.err = err
+		return status
+	}
+	switch metadata := metadata.(type) {
+	case plugin.Metadata:
+		metadata, err = metadata.Select(entry.Constraint, shared.Version)
+		if err != nil {
+			status.err = err
+			return status
+		}
+		status.available = metadata.Version
+		_, found := platformDownload(metadata)
+		status.unpublished = !found
+	case manifest.Manifest:
+		// Manifests work on every system
+		status.available = metadata.Version
+	}
+	installed, err := semver.NewVersion(entry.Version)
+	if err != nil {
+		status.err = err
+		return status
+	}
+	available, err := semver.NewVersion(status.available)
+	if err != nil {
+		status.err = err
+		return status
+	}
+	// Like plugin update, a plugin installed with a constraint is outdated if it isn't the newest version matching it
+	if entry.Constraint != "" {
+		status.outdated = !available.Equal(installed)
+	} else {
+		status.outdated = available.GreaterThan(installed)
+	}
+	return status
+}
+
+// checkAllOutdated checks every entry concurrently, returning the statuses in the order of the entries
+func checkAllOutdated(client *http.Client, entries []plugin.Entry) []outdatedStatus {
+	statuses := make([]outdatedStatus, len(entries))
+	var wg sync.WaitGroup
+	for i, entry := range entries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			statuses[i] = checkOutdated(client, entry)
+		}()
+	}
+	wg.Wait()
+	return statuses
+}
+
+// printOutdated writes a table of the statuses to w
+func printOutdated(w io.Writer, statuses []outdatedStatus) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "PLUGIN\tINSTALLED\tAVAILABLE\tSTATUS")
+	for _, status := range statuses {
+		available := status.available
+		if available == "" {
+			available = "-"
+		}
+		fmt.Fprintf(table, "%v\t%v\t%v\t%v\n", status.entry.Name, status.entry.Version, available, status)
+	}
+	return table.Flush()
+}
+
+var OutdatedCmd = &cobra.Command{
+	Use:   "outdated",
+	Short: "List plugins that have a newer version",
+	Long: `Check the metadata of every installed plugin and list the installed and available versions.
+Plugins that are no longer published for your system are flagged.
+Exits with status 2 if a plugin is outdated or no longer published, and with status 1 on errors.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		entries, err := plugin.GetEntries(afero.NewOsFs())
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(entries) == 0 {
+			fmt.Println("No plugins installed")
+			return
+		}
+		statuses := checkAllOutdated(http.DefaultClient, entries)
+		err = printOutdated(os.Stdout, statuses)
+		if err != nil {
+			log.Fatal(err)
+		}
+		exitCode := 0
+		for _, status := range statuses {
+			if status.err != nil {
+				exitCode = 1
+				break
+			}
+			if status.outdated || status.unpublished {
+				exitCode = ExitCodeOutdated
+			}
+		}
+		os.Exit(exitCode)
+	},
+}
@@ -0,0 +1,49 @@
+package plugincmds
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/MTVersionManager/mtvm/plugin"
+)
+
+func TestCheckAllOutdated(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/current.json":
+			_, _ = w.Write([]byte(`{"name": "current", "version": "1.0.0", "downloads": [{"os": "` + runtime.GOOS + `", "arch": "` + runtime.GOARCH + `", "url": "https://example.com/current"}]}`))
+		case "/outdated.json":
+			_, _ = w.Write([]byte(`{"name": "outdated", "version": "1.1.0", "downloads": [{"os": "any", "arch": "any", "url": "https://example.com/outdated", "type": "wasm"}], "releases": [{"version": "1.0.0", "downloads": [{"os": "any", "arch": "any", "url": "https://example.com/outdated", "type": "wasm"}]}]}`))
+		case "/unpublished.json":
+			_, _ = w.Write([]byte(`{"name": "unpublished", "version": "1.0.0", "downloads": [{"os": "loremIpsum", "arch": "amd64", "url": "https://example.com/unpublished"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	statuses := checkAllOutdated(server.Client(), []plugin.Entry{
+		{Name: "current", Version: "1.0.0", MetadataUrl: server.URL + "/current.json"},
+		{Name: "outdated", Version: "1.0.0", MetadataUrl: server.URL + "/outdated.json"},
+		{Name: "unpublished", Version: "1.0.0", MetadataUrl: server.URL + "/unpublished.json"},
+		{Name: "missing", Version: "1.0.0", MetadataUrl: server.URL + "/missing.json"},
+		{Name: "outdated", Version: "1.0.0", MetadataUrl: server.URL + "/outdated.json", Constraint: "~1.0.0"},
+		{Name: "current", Version: "1.0.0", MetadataUrl: server.URL + "/missing.json", MetadataMirrors: []string{server.URL + "/current.json"}},
+	})
+	want := []string{"up to date", "outdated", "no longer published for " + runtime.GOOS + "/" + runtime.GOARCH, "error: 404 Not Found", "up to date", "up to date"}
+	for i, status := range statuses {
+		if status.String() != want[i] {
+			t.Fatalf("want status '%v' for %v, got '%v'", want[i], status.entry.Name, status)
+		}
+	}
+	var table bytes.Buffer
+	if err := printOutdated(&table, statuses); err != nil {
+		t.Fatalf("want no error when printing, got %v", err)
+	}
+	if !strings.Contains(table.String(), "1.1.0") {
+		t.Fatalf("want table to contain the available version, got\n%v", table.String())
+	}
+}
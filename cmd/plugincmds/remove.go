@@ -59,7 +59,7 @@ func (m removeModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "RemoveEntry":
 			m.entryStatus = StatusNotFound
 		case "Remove":
-			m.fileStatus = StatusDone
+			m.fileStatus = StatusNotFound
 		}
 	}
 	if m.entryStatus != StatusNone && m.fileStatus != StatusNone {
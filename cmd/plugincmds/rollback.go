@@ -0,0 +1,36 @@
+package plugincmds
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/MTVersionManager/mtvm/plugin"
+	"github.com/MTVersionManager/mtvm/shared"
+	"github.com/charmbracelet/log"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+var RollbackCmd = &cobra.Command{
+	Use:   "rollback [plugin name]",
+	Short: "Restore the previous build of a plugin",
+	Long: `Restore the build of a plugin that was installed before the last install or update.
+The build that is replaced is kept, so running rollback again undoes it.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		entry, err := plugin.Rollback(args[0], afero.NewOsFs())
+		if errors.Is(err, plugin.ErrNoBackup) {
+			fmt.Printf("There is no previous build of %v to roll back to\n", args[0])
+			os.Exit(1)
+		}
+		if err != nil {
+			log.Fatal("Error when rolling back", "err", err)
+		}
+		if entry == nil {
+			fmt.Printf("%v Restored the previous build of %v\n", shared.CheckMark, args[0])
+			return
+		}
+		fmt.Printf("%v Restored version %v of %v\n", shared.CheckMark, entry.Version, args[0])
+	},
+}
@@ -0,0 +1,202 @@
+package plugincmds
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"unicode/utf8"
+
+	"github.com/MTVersionManager/mtvm/plugin"
+	"github.com/MTVersionManager/mtvm/plugin/index"
+	"github.com/MTVersionManager/mtvm/shared"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+// searchResult is a plugin found by the search command
+type searchResult struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	MetadataUrl string `json:"metadataUrl"`
+	Index       string `json:"index"`
+	Installed   bool   `json:"installed"`
+	// Supported is whether the plugin publishes a build for this system, nil if the metadata couldn't be loaded
+	Supported *bool `json:"supported"`
+	score     int
+}
+
+// fuzzyScore matches query against text ignoring case, the bool is false if they don't match.
+// The query matches if its characters appear in the text in the same order,
+// and the score is higher the closer together they are, with a bonus for matching at the start.
+func fuzzyScore(query, text string) (int, bool) {
+	query = strings.ToLower(query)
+	text = strings.ToLower(text)
+	if query == "" {
+		return 0, true
+	}
+	if i := strings.Index(text, query); i != -1 {
+		score := 100 + utf8.RuneCountInString(query)*10
+		if i == 0 {
+			score += 50
+		}
+		if len(query) == len(text) {
+			score += 50
+		}
+		return score, true
+	}
+	score := 0
+	streak := 0
+	queryRunes := []rune(query)
+	matched := 0
+	for _, r := range text {
+		if matched < len(queryRunes) && r == queryRunes[matched] {
+			matched++
+			streak++
+			score += streak
+		} else {
+			streak = 0
+		}
+	}
+	return score, matched == len(queryRunes)
+}
+
+// searchIndexes returns the plugins in the indexes whose name or description match the query, best matches first.
+// Plugins in more than one index are only returned for the first one, like index.Resolve uses them.
+// Indexes that can't be loaded are reported on w and skipped.
+func searchIndexes(client *http.Client, indexUrls []string, query string, w io.Writer) []searchResult {
+	var results []searchResult
+	seen := make(map[string]bool)
+	for _, indexUrl := range indexUrls {
+		pluginIndex, err := index.Fetch(client, indexUrl)
+		if err != nil {
+			fmt.Fprintf(w, "%v Skipping index %v: %v\n", shared.CrossMark, indexUrl, err)
+			continue
+		}
+		for name, indexed := range pluginIndex.Plugins {
+			if seen[name] {
+				continue
+			}
+			nameScore, nameMatches := fuzzyScore(query, name)
+			descriptionScore, descriptionMatches := fuzzyScore(query, indexed.Description)
+			if !nameMatches && !descriptionMatches {
+				continue
+			}
+			seen[name] = true
+			results = append(results, searchResult{
+				Name:        name,
+				Description: indexed.Description,
+				MetadataUrl: indexed.MetadataUrl,
+				Index:       indexUrl,
+				// Matching the name is worth more than matching the description
+				score: max(nameScore*2, descriptionScore),
+			})
+		}
+	}
+	slices.SortFunc(results, func(a, b searchResult) int {
+		return cmp.Or(cmp.Compare(b.score, a.score), cmp.Compare(a.Name, b.Name))
+	})
+	return results
+}
+
+// addSearchDetails marks the installed results and loads the metadata of every result concurrently to check whether it supports this system
+func addSearchDetails(client *http.Client, results []searchResult, entries []plugin.Entry) {
+	var wg sync.WaitGroup
+	for i := range results {
+		results[i].Installed = slices.ContainsFunc(entries, func(entry plugin.Entry) bool { return entry.Name == results[i].Name })
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			metadata, err := fetchMetadata(client, results[i].MetadataUrl)
+			if err != nil {
+				return
+			}
+			// Manifests work on every system
+			supported := true
+			if metadata, ok := metadata.(plugin.Metadata); ok {
+				metadata, err = metadata.Select("", shared.Version)
+				if err == nil {
+					_, supported = platformDownload(metadata)
+				} else {
+					supported = false
+				}
+			}
+			results[i].Supported = &supported
+		}()
+	}
+	wg.Wait()
+}
+
+// printSearchResults writes a table of the results to w
+func printSearchResults(w io.Writer, results []searchResult) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "NAME\tINSTALLED\tYOUR SYSTEM\tDESCRIPTION")
+	for _, result := range results {
+		installed := ""
+		if result.Installed {
+			installed = shared.CheckMark
+		}
+		supported := "unknown"
+		if result.Supported != nil && *result.Supported {
+			supported = "supported"
+		} else if result.Supported != nil {
+			supported = "unsupported"
+		}
+		fmt.Fprintf(table, "%v\t%v\t%v\t%v\n", result.Name, installed, supported, result.Description)
+	}
+	return table.Flush()
+}
+
+var SearchCmd = &cobra.Command{
+	Use:   "search [query]",
+	Short: "Search for plugins",
+	Long: `Search the plugin indexes configured with pluginIndexes for plugins whose name or description match the query.
+The query matches if its letters appear in that order, so "gl" matches golang.`,
+	Args:    cobra.ExactArgs(1),
+	Aliases: []string{"s", "find"},
+	Run: func(cmd *cobra.Command, args []string) {
+		jsonFlagUsed, err := cmd.Flags().GetBool("json")
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(shared.Configuration.PluginIndexes) == 0 {
+			fmt.Println("No plugin indexes are configured, add their urls to pluginIndexes in the configuration")
+			os.Exit(1)
+		}
+		entries, err := plugin.GetEntries(afero.NewOsFs())
+		if err != nil {
+			log.Fatal(err)
+		}
+		results := searchIndexes(http.DefaultClient, shared.Configuration.PluginIndexes, args[0], os.Stderr)
+		addSearchDetails(http.DefaultClient, results, entries)
+		if jsonFlagUsed {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "	")
+			// An empty list is printed as [] rather than null
+			err = encoder.Encode(append([]searchResult{}, results...))
+			if err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+		if len(results) == 0 {
+			fmt.Printf("No plugins match %v\n", args[0])
+			return
+		}
+		err = printSearchResults(os.Stdout, results)
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	SearchCmd.Flags().Bool("json", false, "print the results as JSON")
+}
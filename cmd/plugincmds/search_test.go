@@ -0,0 +1,72 @@
+package plugincmds
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"github.com/MTVersionManager/mtvm/plugin"
+)
+
+func TestFuzzyScore(t *testing.T) {
+	tests := map[string]struct {
+		query   string
+		text    string
+		matches bool
+	}{
+		"substring":      {query: "lang", text: "golang", matches: true},
+		"subsequence":    {query: "gl", text: "golang", matches: true},
+		"ignores case":   {query: "GO", text: "golang", matches: true},
+		"wrong order":    {query: "lg", text: "go", matches: false},
+		"missing letter": {query: "rust", text: "golang", matches: false},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, matches := fuzzyScore(tt.query, tt.text); matches != tt.matches {
+				t.Fatalf("want match %v, got %v", tt.matches, matches)
+			}
+		})
+	}
+	exact, _ := fuzzyScore("go", "go")
+	prefix, _ := fuzzyScore("go", "golang")
+	scattered, _ := fuzzyScore("go", "gleam-o")
+	if !(exact > prefix && prefix > scattered) {
+		t.Fatalf("want exact > prefix > scattered, got %v, %v and %v", exact, prefix, scattered)
+	}
+}
+
+func TestSearchIndexes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/index.json":
+			_, _ = w.Write([]byte(`{"plugins": {
+	"go": {"metadataUrl": "go.json", "description": "The Go toolchain"},
+	"zig": {"metadataUrl": "zig.json", "description": "Zig, works with go projects too"},
+	"rust": {"metadataUrl": "rust.json", "description": "Rustup"}
+}}`))
+		case "/go.json":
+			_, _ = w.Write([]byte(`{"name": "go", "version": "1.0.0", "downloads": [{"os": "` + runtime.GOOS + `", "arch": "` + runtime.GOARCH + `", "url": "https://example.com/go"}]}`))
+		case "/zig.json":
+			_, _ = w.Write([]byte(`{"name": "zig", "version": "1.0.0", "downloads": [{"os": "loremIpsum", "arch": "amd64", "url": "https://example.com/zig"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	results := searchIndexes(server.Client(), []string{server.URL + "/index.json", server.URL + "/missing.json"}, "go", io.Discard)
+	if len(results) != 2 || results[0].Name != "go" || results[1].Name != "zig" {
+		t.Fatalf("want go matched by name before zig matched by description, got %v", results)
+	}
+	addSearchDetails(server.Client(), results, []plugin.Entry{{Name: "zig"}})
+	if results[0].Installed || !results[1].Installed {
+		t.Fatalf("want only zig to be installed, got %v", results)
+	}
+	if results[0].Supported == nil || !*results[0].Supported {
+		t.Fatal("want go to support this system")
+	}
+	if results[1].Supported == nil || *results[1].Supported {
+		t.Fatal("want zig to not support this system")
+	}
+}
@@ -0,0 +1,82 @@
+package plugincmds
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/MTVersionManager/mtvm/plugin/trust"
+	"github.com/MTVersionManager/mtvm/shared"
+	"github.com/charmbracelet/log"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+var TrustCmd = &cobra.Command{
+	Use:   "trust",
+	Short: "Manage the keys plugin metadata can be signed with",
+	Long: `Manage the keys plugin metadata can be signed with.
+Keys are trusted the first time a plugin signed with them is installed,
+after that metadata for the plugin has to be signed with a trusted key.`,
+}
+
+var trustListCmd = &cobra.Command{
+	Use:     "list",
+	Short:   "List the trusted keys",
+	Long:    `List the trusted keys with their IDs and the plugins they were trusted for`,
+	Args:    cobra.NoArgs,
+	Aliases: []string{"ls"},
+	Run: func(cmd *cobra.Command, args []string) {
+		keys, err := trust.Keys(afero.NewOsFs())
+		if err != nil {
+			log.Fatal("Error when getting trusted keys", "err", err)
+		}
+		if len(keys) == 0 {
+			fmt.Println("No keys trusted")
+			return
+		}
+		for _, key := range keys {
+			fmt.Printf("%v %v %v\n", key.ID(), key.Name, key.PublicKey)
+		}
+	},
+}
+
+var trustAddCmd = &cobra.Command{
+	Use:   "add [plugin name] [public key]",
+	Short: "Trust a key",
+	Long:  `Trust a base64 encoded ed25519 public key for the plugin with the name specified`,
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		key := trust.Key{Name: args[0], PublicKey: args[1]}
+		err := trust.Add(key, afero.NewOsFs())
+		if err != nil {
+			log.Fatal("Error when trusting key", "err", err)
+		}
+		fmt.Printf("%v Trusted key %v for %v\n", shared.CheckMark, key.ID(), key.Name)
+	},
+}
+
+var trustRemoveCmd = &cobra.Command{
+	Use:     "remove [plugin name or key ID]",
+	Short:   "Stop trusting a key",
+	Long:    `Stop trusting the keys trusted for the plugin with the name specified, or the key with the ID specified`,
+	Args:    cobra.ExactArgs(1),
+	Aliases: []string{"r", "rm"},
+	Run: func(cmd *cobra.Command, args []string) {
+		err := trust.Remove(args[0], afero.NewOsFs())
+		if errors.Is(err, trust.ErrKeyNotFound) {
+			fmt.Printf("No trusted key has the name or ID %v\n", args[0])
+			os.Exit(1)
+		}
+		if err != nil {
+			log.Fatal("Error when removing key", "err", err)
+		}
+		fmt.Printf("%v Stopped trusting %v\n", shared.CheckMark, args[0])
+	},
+}
+
+func init() {
+	TrustCmd.AddCommand(trustListCmd)
+	TrustCmd.AddCommand(trustAddCmd)
+	TrustCmd.AddCommand(trustRemoveCmd)
+}
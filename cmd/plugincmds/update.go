@@ -0,0 +1,281 @@
+package plugincmds
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/MTVersionManager/mtvm/components/downloader"
+	"github.com/MTVersionManager/mtvm/plugin"
+	"github.com/MTVersionManager/mtvm/shared"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+// updateFinishedMsg is sent by the install model of the plugin being updated once it is done
+type updateFinishedMsg struct{}
+
+// updateResult is what happened when updating a plugin
+type updateResult struct {
+	entry plugin.Entry
+	// newVersion is set if the plugin was updated
+	newVersion string
+	upToDate   bool
+	noDownload bool
+	err        error
+}
+
+func (r updateResult) String() string {
+	switch {
+	case r.err != nil:
+		return fmt.Sprintf("%v %v: %v", shared.CrossMark, r.entry.Name, r.err)
+	case r.newVersion != "" && r.newVersion == r.entry.Version:
+		return fmt.Sprintf("%v %v: installed %v", shared.CheckMark, r.entry.Name, r.newVersion)
+	case r.newVersion != "":
+		return fmt.Sprintf("%v %v: %v → %v", shared.CheckMark, r.entry.Name, r.entry.Version, r.newVersion)
+	case r.noDownload:
+		return fmt.Sprintf("%v %v: the new version does not provide a download for your system", shared.CrossMark, r.entry.Name)
+	case r.entry.Constraint != "":
+		return fmt.Sprintf("%v %v: already up to date (%v, pinned to %v)", shared.CheckMark, r.entry.Name, r.entry.Version, r.entry.Constraint)
+	default:
+		return fmt.Sprintf("%v %v: already up to date (%v)", shared.CheckMark, r.entry.Name, r.entry.Version)
+	}
+}
+
+// What an updateModel installs the plugins of its entries for
+const (
+	// modeUpdate installs versions newer than the installed ones, or the newest ones matching their constraints
+	modeUpdate = iota
+	// modeReinstall downloads the installed versions of the plugins again, which plugin verify uses to repair them
+	modeReinstall
+	// modeImport installs the versions in the entries, which plugin import uses
+	modeImport
+)
+
+// updateModel updates plugins one after another by installing them from their metadata url again.
+// Its view combines the results of the plugins that are done with the progress of the current one.
+// Plugins are updated one at a time, so a prompt to trust a key always belongs to the plugin shown.
+type updateModel struct {
+	entries         []plugin.Entry
+	current         int
+	installer       installModel
+	results         []updateResult
+	requireChecksum bool
+	fileSystem      afero.Fs
+	done            bool
+	mode            int
+	// canceled is true once a download was canceled, which stops the whole update without finishing it,
+	// so the command cleans up after the plugin that was being installed
+	canceled bool
+}
+
+func initialUpdateModel(entries []plugin.Entry, requireChecksum bool) updateModel {
+	return initialModeModel(entries, requireChecksum, modeUpdate)
+}
+
+// initialModeModel creates a model that installs the plugins of the entries in the given mode
+func initialModeModel(entries []plugin.Entry, requireChecksum bool, mode int) updateModel {
+	m := updateModel{
+		entries:         entries,
+		requireChecksum: requireChecksum,
+		fileSystem:      afero.NewOsFs(),
+		mode:            mode,
+	}
+	if len(entries) > 0 {
+		m.installer = m.newInstaller(entries[0])
+	}
+	return m
+}
+
+// newInstaller creates the install model for an entry, which only installs versions newer than the installed one,
+// or the newest version matching the constraint the plugin was installed with
+func (m updateModel) newInstaller(entry plugin.Entry) installModel {
+	installer := initialInstallModel(entry.MetadataUrl, m.requireChecksum).useMirrors(entry)
+	installer.constraint = entry.Constraint
+	installer.force = m.mode == modeReinstall
+	// Repairing a plugin must not upgrade it, and imports install the exported versions
+	if m.mode == modeReinstall || m.mode == modeImport {
+		installer.version = entry.Version
+	}
+	installer.fileSystem = m.fileSystem
+	installer.onFinish = func() tea.Msg {
+		return updateFinishedMsg{}
+	}
+	return installer
+}
+
+// next records the result of the current plugin and starts updating the next one
+func (m updateModel) next(result updateResult) (updateModel, tea.Cmd) {
+	m.results = append(m.results, result)
+	m.current++
+	if m.current >= len(m.entries) {
+		m.done = true
+		return m, tea.Quit
+	}
+	m.installer = m.newInstaller(m.entries[m.current])
+	return m, m.installer.Init()
+}
+
+func (m updateModel) Init() tea.Cmd {
+	if len(m.entries) == 0 {
+		return tea.Quit
+	}
+	return m.installer.Init()
+}
+
+func (m updateModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if m.done || m.canceled {
+		return m, nil
+	}
+	entry := m.entries[m.current]
+	switch msg := msg.(type) {
+	case error:
+		// A failing plugin doesn't stop the others from being updated
+		err := m.installer.cleanup()
+		if err != nil {
+			msg = fmt.Errorf("%w, and cleaning up failed: %w", msg, err)
+		}
+		return m.next(updateResult{entry: entry, err: msg})
+	case downloader.DownloadCanceledMsg:
+		// Stopping the download and the download itself both report it, the first one stops the update and the other is dropped.
+		// The install model would finish normally, which would count the plugin as up to date and start the next one.
+		m.canceled = true
+		m.installer.canceled = true
+		return m, tea.Quit
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" && !m.installer.askingTrust {
+			return m, tea.Batch(m.installer.downloader.StopDownload(), tea.Quit)
+		}
+	case updateFinishedMsg:
+		result := updateResult{
+			entry:      entry,
+			upToDate:   m.installer.versionInstalled,
+			noDownload: m.installer.noDownload,
+		}
+		if m.installer.done {
+			result.newVersion = m.installer.pluginInfo.Version.String()
+		}
+		return m.next(result)
+	}
+	updated, cmd := m.installer.Update(msg)
+	m.installer = updated.(installModel)
+	return m, cmd
+}
+
+func (m updateModel) View() string {
+	var view strings.Builder
+	for _, result := range m.results {
+		view.WriteString(result.String() + "\n")
+	}
+	if !m.done {
+		verb := "Updating"
+		switch m.mode {
+		case modeReinstall:
+			verb = "Reinstalling"
+		case modeImport:
+			verb = "Installing"
+		}
+		view.WriteString(fmt.Sprintf("[%v/%v] %v %v\n", m.current+1, len(m.entries), verb, m.entries[m.current].Name))
+		view.WriteString(m.installer.View())
+	}
+	return view.String()
+}
+
+// counts counts the results by what happened
+func (m updateModel) counts() (updated, upToDate, failed int) {
+	for _, result := range m.results {
+		switch {
+		case result.err != nil || result.noDownload:
+			failed++
+		case result.newVersion != "":
+			updated++
+		default:
+			upToDate++
+		}
+	}
+	return updated, upToDate, failed
+}
+
+// entriesToUpdate returns the entries with the given names, or all entries if all is true.
+// The names that aren't installed are returned separately.
+func entriesToUpdate(entries []plugin.Entry, names []string, all bool) ([]plugin.Entry, []string) {
+	if all {
+		return entries, nil
+	}
+	var selected []plugin.Entry
+	var notInstalled []string
+	for _, name := range names {
+		i := slices.IndexFunc(entries, func(entry plugin.Entry) bool { return entry.Name == name })
+		if i == -1 {
+			notInstalled = append(notInstalled, name)
+			continue
+		}
+		selected = append(selected, entries[i])
+	}
+	return selected, notInstalled
+}
+
+var UpdateCmd = &cobra.Command{
+	Use:   "update [plugin names]",
+	Short: "Update plugins",
+	Long: `Update the plugins with the names specified, or all plugins with --all, to the newest version in their metadata.
+Plugins installed with a version or constraint, like loremIpsum@^1.2, are updated to the newest version matching it.`,
+	Aliases: []string{"u", "up", "upgrade"},
+	Run: func(cmd *cobra.Command, args []string) {
+		all, err := cmd.Flags().GetBool("all")
+		if err != nil {
+			log.Fatal(err)
+		}
+		requireChecksum, err := cmd.Flags().GetBool("require-checksum")
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(args) == 0 && !all {
+			fmt.Println("Please specify the plugins to update or use --all")
+			os.Exit(1)
+		}
+		entries, err := plugin.GetEntries(afero.NewOsFs())
+		if err != nil {
+			log.Fatal(err)
+		}
+		selected, notInstalled := entriesToUpdate(entries, args, all)
+		for _, name := range notInstalled {
+			fmt.Printf("%v %v is not installed\n", shared.CrossMark, name)
+		}
+		if len(selected) == 0 {
+			if all {
+				fmt.Println("No plugins installed")
+			}
+			os.Exit(1)
+		}
+		p := tea.NewProgram(initialUpdateModel(selected, requireChecksum || shared.Configuration.RequireChecksum))
+		model, err := p.Run()
+		if err != nil {
+			log.Fatal(err)
+		}
+		updateModel, ok := model.(updateModel)
+		if !ok {
+			log.Fatal("unexpected model type")
+		}
+		if !updateModel.done {
+			err = updateModel.installer.cleanup()
+			if err != nil {
+				log.Println("Error when cleaning up after the update:", err)
+			}
+			os.Exit(1)
+		}
+		updated, upToDate, failed := updateModel.counts()
+		fmt.Printf("%v updated, %v already up to date, %v failed\n", updated, upToDate, failed)
+		if failed > 0 || len(notInstalled) > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	UpdateCmd.Flags().BoolP("all", "a", false, "update all installed plugins")
+	UpdateCmd.Flags().Bool("require-checksum", false, "refuse to install a plugin without a checksum, also enabled by the requireChecksum setting")
+}
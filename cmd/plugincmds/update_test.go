@@ -0,0 +1,125 @@
+package plugincmds
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/MTVersionManager/mtvm/components/downloader"
+	"github.com/MTVersionManager/mtvm/plugin"
+	"github.com/MTVersionManager/mtvm/plugin/trust"
+	"github.com/MTVersionManager/mtvm/shared"
+	"github.com/Masterminds/semver/v3"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/afero"
+)
+
+func TestEntriesToUpdate(t *testing.T) {
+	entries := []plugin.Entry{{Name: "loremIpsum"}, {Name: "dolorSitAmet"}}
+	selected, notInstalled := entriesToUpdate(entries, []string{"dolorSitAmet", "consectetur"}, false)
+	if len(selected) != 1 || selected[0].Name != "dolorSitAmet" {
+		t.Fatalf("want only dolorSitAmet selected, got %v", selected)
+	}
+	if !slices.Equal(notInstalled, []string{"consectetur"}) {
+		t.Fatalf("want consectetur not installed, got %v", notInstalled)
+	}
+	selected, _ = entriesToUpdate(entries, nil, true)
+	if len(selected) != len(entries) {
+		t.Fatalf("want all entries selected, got %v", selected)
+	}
+}
+
+func TestUpdateModelContinuesAfterError(t *testing.T) {
+	model := initialUpdateModel([]plugin.Entry{
+		{Name: "loremIpsum", Version: "1.0.0", MetadataUrl: "https://example.com/loremIpsum.json"},
+		{Name: "dolorSitAmet", Version: "1.0.0", MetadataUrl: "https://example.com/dolorSitAmet.json"},
+	}, false)
+	updated, _ := model.Update(errors.New("loremIpsum failed"))
+	model = updated.(updateModel)
+	if model.done || model.current != 1 {
+		t.Fatalf("want second plugin to be updated next, got current %v and done %v", model.current, model.done)
+	}
+	// The install model of the second plugin finishing the update
+	model.installer.pluginInfo = pluginDownloadInfo{Name: "dolorSitAmet", Version: semver.MustParse("1.1.0")}
+	model.installer.done = true
+	updated, cmd := model.Update(shared.SuccessMsg("UpdateEntries"))
+	if cmd == nil {
+		t.Fatal("want not nil command, got nil")
+	}
+	updated, cmd = updated.Update(cmd())
+	model = updated.(updateModel)
+	if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Fatal("want program to quit after the last plugin")
+	}
+	updatedCount, upToDate, failed := model.counts()
+	if updatedCount != 1 || upToDate != 0 || failed != 1 {
+		t.Fatalf("want 1 updated and 1 failed, got %v updated, %v up to date and %v failed", updatedCount, upToDate, failed)
+	}
+}
+
+func TestUpdateModelContinuesAfterRejectedKey(t *testing.T) {
+	model := initialUpdateModel([]plugin.Entry{
+		{Name: "loremIpsum", Version: "1.0.0", MetadataUrl: "https://example.com/loremIpsum.json"},
+		{Name: "dolorSitAmet", Version: "1.0.0", MetadataUrl: "https://example.com/dolorSitAmet.json"},
+	}, false)
+	updated, _ := model.Update(untrustedKeyMsg{})
+	updated, cmd := updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	if cmd == nil {
+		t.Fatal("want not nil command, got nil")
+	}
+	updated, _ = updated.Update(cmd())
+	model = updated.(updateModel)
+	if model.done || model.current != 1 {
+		t.Fatalf("want second plugin to be updated next, got current %v and done %v", model.current, model.done)
+	}
+	if len(model.results) != 1 || !errors.Is(model.results[0].err, trust.ErrUntrustedKey) {
+		t.Fatalf("want the rejected key to be recorded for the first plugin, got %v", model.results)
+	}
+}
+
+func TestUpdateModelStopsWhenCanceled(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	model := initialUpdateModel([]plugin.Entry{
+		{Name: "loremIpsum", Version: "1.0.0", MetadataUrl: "https://example.com/loremIpsum.json"},
+		{Name: "dolorSitAmet", Version: "1.0.0", MetadataUrl: "https://example.com/dolorSitAmet.json"},
+	}, false)
+	model.fileSystem = fs
+	// The install model of the first plugin downloading the plugin file
+	model.installer.fileSystem = fs
+	model.installer.pluginInfo = pluginDownloadInfo{Name: "loremIpsum", Type: shared.PluginTypeRPC, Url: "https://example.com/loremIpsum", Version: semver.MustParse("1.1.0")}
+	model.installer.step = 1
+	model.installer.downloader = model.installer.pluginDownloader()
+	updated, _ := model.Update(downloader.DownloadStartedMsg{Cancel: func() {}})
+	updated, cmd := updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+	if cmd == nil {
+		t.Fatal("want not nil command, got nil")
+	}
+	updated, cmd = updated.Update(cmd())
+	if cmd == nil {
+		t.Fatal("want not nil command, got nil")
+	}
+	if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Fatal("want program to quit when the download is canceled")
+	}
+	// The download reports being canceled too, after the update was stopped
+	updated, cmd = updated.Update(downloader.DownloadCanceledMsg{})
+	model = updated.(updateModel)
+	if cmd != nil {
+		t.Fatalf("want the second cancellation to be dropped, got command returning %v", cmd())
+	}
+	if model.done || model.current != 0 || len(model.results) != 0 {
+		t.Fatalf("want the update to stop at the first plugin without a result, got current %v, done %v and results %v", model.current, model.done, model.results)
+	}
+	// The commands clean up after an update that didn't finish
+	err := model.installer.cleanup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	exists, err := afero.Exists(fs, plugin.TempFile("loremIpsum", shared.PluginTypeRPC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exists {
+		t.Fatal("want the partial plugin file to be removed")
+	}
+}
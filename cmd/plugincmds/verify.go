@@ -0,0 +1,282 @@
+package plugincmds
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/MTVersionManager/mtvm/components/downloader"
+	"github.com/MTVersionManager/mtvm/plugin"
+	"github.com/MTVersionManager/mtvm/shared"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+// Kinds of problems verifyPlugins finds besides problemMissingFile and problemLoadFailure
+const (
+	problemChecksumMismatch = "checksum mismatch"
+	problemOrphanFile       = "file without entry"
+	problemShadowedFile     = "shadowed file"
+	problemLeftoverFile     = "leftover temporary file"
+	problemUnknownFile      = "unknown file"
+)
+
+// verifyProblem is a difference between plugins.json and the plugin directory
+type verifyProblem struct {
+	kind       string
+	pluginName string
+	// file is the file in the plugin directory the problem is about, empty for problems with an entry
+	file string
+	err  error
+}
+
+func (p verifyProblem) String() string {
+	switch {
+	case p.pluginName == "":
+		return fmt.Sprintf("%v %v", p.kind, p.file)
+	case p.err != nil:
+		return fmt.Sprintf("%v: %v: %v", p.pluginName, p.kind, p.err)
+	case p.file != "":
+		return fmt.Sprintf("%v: %v %v", p.pluginName, p.kind, p.file)
+	default:
+		return fmt.Sprintf("%v: %v", p.pluginName, p.kind)
+	}
+}
+
+// needsReinstall reports whether plugin verify --fix repairs the problem by installing the plugin from its metadata url again
+func (p verifyProblem) needsReinstall() bool {
+	return p.kind == problemMissingFile || p.kind == problemLoadFailure || p.kind == problemChecksumMismatch
+}
+
+// needsRemoval reports whether plugin verify --fix repairs the problem by removing the file
+func (p verifyProblem) needsRemoval() bool {
+	return p.kind == problemOrphanFile || p.kind == problemShadowedFile || p.kind == problemLeftoverFile
+}
+
+// pluginFileName returns the plugin name and type a file in the plugin directory belongs to, the bool is false if it isn't a plugin file.
+// The files of the entries are matched first, so plugins with a "." in their name are found even if their file has no extension.
+func pluginFileName(fileName string, entries []plugin.Entry) (string, string, bool) {
+	for _, entry := range entries {
+		for _, pluginType := range shared.PluginTypes {
+			if filepath.Base(shared.PluginFile(entry.Name, pluginType)) == fileName {
+				return entry.Name, pluginType, true
+			}
+		}
+	}
+	var extensionless string
+	for _, pluginType := range shared.PluginTypes {
+		extension := strings.TrimPrefix(filepath.Base(shared.PluginFile("x", pluginType)), "x")
+		if extension == "" {
+			// RPC plugins have no extension on unix, so they are only matched if nothing else matches
+			extensionless = pluginType
+			continue
+		}
+		if name, ok := strings.CutSuffix(fileName, extension); ok && name != "" {
+			return name, pluginType, true
+		}
+	}
+	if extensionless != "" && !strings.Contains(fileName, ".") {
+		return fileName, extensionless, true
+	}
+	return "", "", false
+}
+
+// verifyChecksum checks the installed plugin file against the checksum in its entry
+func verifyChecksum(entry plugin.Entry, pluginType string, fs afero.Fs) error {
+	checksumHash, expected, err := downloader.ParseChecksum(entry.Checksum)
+	if err != nil {
+		return err
+	}
+	file, err := fs.Open(shared.PluginFile(entry.Name, pluginType))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(checksumHash, file)
+	if err != nil {
+		return err
+	}
+	if actual := checksumHash.Sum(nil); !bytes.Equal(actual, expected) {
+		return &downloader.ChecksumError{Expected: hex.EncodeToString(expected), Actual: hex.EncodeToString(actual)}
+	}
+	return nil
+}
+
+// verifyPlugins compares plugins.json with the files in the plugin directory and returns the problems it finds.
+// load is called for every plugin whose file passed the other checks, to find files that fail to load.
+func verifyPlugins(fs afero.Fs, load func(pluginName string) error) ([]verifyProblem, error) {
+	entries, err := plugin.GetEntries(fs)
+	if err != nil {
+		return nil, err
+	}
+	var problems []verifyProblem
+	for _, entry := range entries {
+		types, err := plugin.InstalledTypes(entry.Name, fs)
+		if err != nil {
+			return nil, err
+		}
+		if len(types) == 0 {
+			problems = append(problems, verifyProblem{kind: problemMissingFile, pluginName: entry.Name})
+			continue
+		}
+		// Only the first file is loaded, the others would be loaded instead if it was removed
+		for _, pluginType := range types[1:] {
+			problems = append(problems, verifyProblem{kind: problemShadowedFile, pluginName: entry.Name, file: shared.PluginFile(entry.Name, pluginType)})
+		}
+		if entry.Checksum != "" {
+			err = verifyChecksum(entry, types[0], fs)
+			if err != nil {
+				problems = append(problems, verifyProblem{kind: problemChecksumMismatch, pluginName: entry.Name, err: err})
+				continue
+			}
+		}
+		err = load(entry.Name)
+		if err != nil {
+			problems = append(problems, verifyProblem{kind: problemLoadFailure, pluginName: entry.Name, err: err})
+		}
+	}
+	files, err := afero.ReadDir(fs, shared.Configuration.PluginDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		path := filepath.Join(shared.Configuration.PluginDir, file.Name())
+		if tempName, ok := strings.CutSuffix(file.Name(), ".partial"); ok {
+			pluginName, _, ok := pluginFileName(tempName, entries)
+			if !ok {
+				pluginName = tempName
+			}
+			problems = append(problems, verifyProblem{kind: problemLeftoverFile, pluginName: pluginName, file: path})
+			continue
+		}
+		pluginName, _, ok := pluginFileName(file.Name(), entries)
+		if !ok {
+			problems = append(problems, verifyProblem{kind: problemUnknownFile, file: path})
+			continue
+		}
+		if !slices.ContainsFunc(entries, func(entry plugin.Entry) bool { return entry.Name == pluginName }) {
+			problems = append(problems, verifyProblem{kind: problemOrphanFile, pluginName: pluginName, file: path})
+		}
+	}
+	return problems, nil
+}
+
+// loadAndClose loads a plugin to check that it works, stopping it again if it runs in another process or a runtime
+func loadAndClose(pluginName string) error {
+	loaded, err := shared.LoadPlugin(pluginName)
+	if err != nil {
+		return err
+	}
+	if closer, ok := loaded.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// fixProblems removes the files of the problems that are fixed by removing them,
+// and returns the entries of the plugins that have to be installed again.
+// It returns the problems it can't fix.
+func fixProblems(problems []verifyProblem, entries []plugin.Entry, fs afero.Fs) ([]plugin.Entry, []verifyProblem) {
+	var reinstall []plugin.Entry
+	var unfixed []verifyProblem
+	for _, problem := range problems {
+		switch {
+		case problem.needsRemoval():
+			err := fs.Remove(problem.file)
+			if err != nil && !os.IsNotExist(err) {
+				problem.err = errors.Join(problem.err, err)
+				unfixed = append(unfixed, problem)
+				continue
+			}
+			fmt.Printf("%v Removed %v\n", shared.CheckMark, problem.file)
+		case problem.needsReinstall():
+			i := slices.IndexFunc(entries, func(entry plugin.Entry) bool { return entry.Name == problem.pluginName })
+			if i != -1 && !slices.ContainsFunc(reinstall, func(entry plugin.Entry) bool { return entry.Name == problem.pluginName }) {
+				reinstall = append(reinstall, entries[i])
+			}
+		default:
+			unfixed = append(unfixed, problem)
+		}
+	}
+	return reinstall, unfixed
+}
+
+var VerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check that plugins.json matches the installed plugin files",
+	Long: `Check that every plugin in plugins.json has a plugin file that loads and matches the checksum it was installed with,
+and that every file in the plugin directory belongs to an installed plugin.
+With --fix, plugins with missing, broken or modified files are installed again from their metadata url,
+and files that don't belong to an installed plugin are removed. Unknown files are left alone.
+Exits with a non-zero status if problems are found that weren't fixed.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		fix, err := cmd.Flags().GetBool("fix")
+		if err != nil {
+			log.Fatal(err)
+		}
+		fs := afero.NewOsFs()
+		problems, err := verifyPlugins(fs, loadAndClose)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(problems) == 0 {
+			fmt.Printf("%v No problems found\n", shared.CheckMark)
+			return
+		}
+		for _, problem := range problems {
+			fmt.Printf("%v %v\n", shared.CrossMark, problem)
+		}
+		if !fix {
+			fmt.Println("Run plugin verify --fix to repair them")
+			os.Exit(1)
+		}
+		entries, err := plugin.GetEntries(fs)
+		if err != nil {
+			log.Fatal(err)
+		}
+		reinstall, unfixed := fixProblems(problems, entries, fs)
+		failed := len(unfixed)
+		if len(reinstall) > 0 {
+			p := tea.NewProgram(initialModeModel(reinstall, shared.Configuration.RequireChecksum, modeReinstall))
+			model, err := p.Run()
+			if err != nil {
+				log.Fatal(err)
+			}
+			reinstallModel, ok := model.(updateModel)
+			if !ok {
+				log.Fatal("unexpected model type")
+			}
+			if !reinstallModel.done {
+				err = reinstallModel.installer.cleanup()
+				if err != nil {
+					log.Println("Error when cleaning up after reinstalling:", err)
+				}
+				os.Exit(1)
+			}
+			_, _, reinstallFailed := reinstallModel.counts()
+			failed += reinstallFailed
+		}
+		for _, problem := range unfixed {
+			fmt.Printf("%v Not fixed: %v\n", shared.CrossMark, problem)
+		}
+		if failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	VerifyCmd.Flags().Bool("fix", false, "repair the problems found")
+}
@@ -0,0 +1,171 @@
+package plugincmds
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/MTVersionManager/mtvm/plugin"
+	"github.com/MTVersionManager/mtvm/shared"
+	"github.com/spf13/afero"
+)
+
+func TestPluginFileName(t *testing.T) {
+	tests := map[string]struct {
+		fileName string
+		wantName string
+		wantType string
+		wantOk   bool
+	}{
+		"native":   {fileName: filepath.Base(shared.PluginFile("loremIpsum", shared.PluginTypeNative)), wantName: "loremIpsum", wantType: shared.PluginTypeNative, wantOk: true},
+		"rpc":      {fileName: filepath.Base(shared.PluginFile("loremIpsum", shared.PluginTypeRPC)), wantName: "loremIpsum", wantType: shared.PluginTypeRPC, wantOk: true},
+		"wasm":     {fileName: "loremIpsum.wasm", wantName: "loremIpsum", wantType: shared.PluginTypeWasm, wantOk: true},
+		"manifest": {fileName: "loremIpsum.json", wantName: "loremIpsum", wantType: shared.PluginTypeManifest, wantOk: true},
+		"unknown":  {fileName: "loremIpsum.txt"},
+		"dotted rpc entry": {
+			fileName: filepath.Base(shared.PluginFile("lorem.ipsum", shared.PluginTypeRPC)),
+			wantName: "lorem.ipsum",
+			wantType: shared.PluginTypeRPC,
+			wantOk:   true,
+		},
+		"dotted wasm entry": {fileName: "lorem.ipsum.wasm", wantName: "lorem.ipsum", wantType: shared.PluginTypeWasm, wantOk: true},
+	}
+	entries := []plugin.Entry{{Name: "lorem.ipsum"}}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			pluginName, pluginType, ok := pluginFileName(tt.fileName, entries)
+			if pluginName != tt.wantName || pluginType != tt.wantType || ok != tt.wantOk {
+				t.Fatalf("want %v, %v and %v, got %v, %v and %v", tt.wantName, tt.wantType, tt.wantOk, pluginName, pluginType, ok)
+			}
+		})
+	}
+}
+
+func TestVerifyPlugins(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	shared.Configuration.PluginDir = "/plugins"
+	writeFile := func(path, content string) {
+		err := afero.WriteFile(fs, path, []byte(content), 0o666)
+		if err != nil {
+			t.Fatalf("want no error when writing %v, got %v", path, err)
+		}
+	}
+	addEntry := func(pluginName, content string) {
+		pluginFile := shared.PluginFile(pluginName, shared.PluginTypeNative)
+		writeFile(pluginFile, content)
+		checksum, err := plugin.FileChecksum(pluginName, shared.PluginTypeNative, fs)
+		if err != nil {
+			t.Fatalf("want no error when getting the checksum, got %v", err)
+		}
+		err = plugin.UpdateEntries(plugin.Entry{Name: pluginName, Version: "1.0.0", MetadataUrl: "https://example.com", Checksum: checksum}, fs)
+		if err != nil {
+			t.Fatalf("want no error when adding the entry, got %v", err)
+		}
+	}
+	addEntry("good", "good")
+	addEntry("missing", "missing")
+	if err := fs.Remove(shared.PluginFile("missing", shared.PluginTypeNative)); err != nil {
+		t.Fatal(err)
+	}
+	addEntry("modified", "original")
+	writeFile(shared.PluginFile("modified", shared.PluginTypeNative), "modified")
+	addEntry("broken", "broken")
+	// RPC plugins have no extension on unix, so only the entry tells that this file isn't unknown
+	writeFile(shared.PluginFile("dotted.name", shared.PluginTypeRPC), "dotted")
+	if err := plugin.UpdateEntries(plugin.Entry{Name: "dotted.name", Version: "1.0.0", MetadataUrl: "https://example.com"}, fs); err != nil {
+		t.Fatal(err)
+	}
+	addEntry("shadowed", "shadowed")
+	writeFile(shared.PluginFile("shadowed", shared.PluginTypeWasm), "shadowed")
+	writeFile(shared.PluginFile("orphan", shared.PluginTypeWasm), "orphan")
+	writeFile(plugin.TempFile("good", shared.PluginTypeNative), "partial")
+	writeFile(plugin.TempFile("dotted.leftover", shared.PluginTypeNative), "partial")
+	writeFile("/plugins/notes.txt", "notes")
+
+	problems, err := verifyPlugins(fs, func(pluginName string) error {
+		if pluginName == "broken" {
+			return errors.New("failed to load")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("want no error, got %v", err)
+	}
+	want := map[string]string{
+		"missing":         problemMissingFile,
+		"modified":        problemChecksumMismatch,
+		"broken":          problemLoadFailure,
+		"shadowed":        problemShadowedFile,
+		"orphan":          problemOrphanFile,
+		"good":            problemLeftoverFile,
+		"dotted.leftover": problemLeftoverFile,
+		"":                problemUnknownFile,
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("want %v problems, got %v", len(want), problems)
+	}
+	for _, problem := range problems {
+		if want[problem.pluginName] != problem.kind {
+			t.Fatalf("want %v for %v, got %v", want[problem.pluginName], problem.pluginName, problem)
+		}
+	}
+
+	entries, err := plugin.GetEntries(fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reinstall, unfixed := fixProblems(problems, entries, fs)
+	var reinstallNames []string
+	for _, entry := range reinstall {
+		reinstallNames = append(reinstallNames, entry.Name)
+	}
+	slices.Sort(reinstallNames)
+	if !slices.Equal(reinstallNames, []string{"broken", "missing", "modified"}) {
+		t.Fatalf("want broken, missing and modified to be reinstalled, got %v", reinstallNames)
+	}
+	if len(unfixed) != 1 || unfixed[0].kind != problemUnknownFile {
+		t.Fatalf("want only the unknown file to be left, got %v", unfixed)
+	}
+	for _, path := range []string{
+		shared.PluginFile("shadowed", shared.PluginTypeWasm),
+		shared.PluginFile("orphan", shared.PluginTypeWasm),
+		plugin.TempFile("good", shared.PluginTypeNative),
+		plugin.TempFile("dotted.leftover", shared.PluginTypeNative),
+	} {
+		if exists, _ := afero.Exists(fs, path); exists {
+			t.Fatalf("want %v to be removed", path)
+		}
+	}
+	if exists, _ := afero.Exists(fs, "/plugins/notes.txt"); !exists {
+		t.Fatal("want unknown files to be left alone")
+	}
+}
+
+func TestFixKeepsRecordedVersion(t *testing.T) {
+	metadata := plugin.Metadata{
+		Name:      "loremIpsum",
+		Version:   "1.1.0",
+		Downloads: []plugin.Download{{OS: plugin.AnyPlatform, Arch: plugin.AnyPlatform, Url: "https://example.com/1.1.0/loremIpsum.wasm", Type: "wasm"}},
+		Releases: []plugin.Release{
+			{Version: "1.0.0", Downloads: []plugin.Download{{OS: plugin.AnyPlatform, Arch: plugin.AnyPlatform, Url: "https://example.com/1.0.0/loremIpsum.wasm", Type: "wasm"}}},
+		},
+	}
+	entry := plugin.Entry{Name: "loremIpsum", Version: "1.0.0", MetadataUrl: "https://example.com/loremIpsum.json"}
+	model := initialModeModel([]plugin.Entry{entry}, false, modeReinstall)
+	if !model.installer.force {
+		t.Fatal("want the plugin to be downloaded again although the version is installed")
+	}
+	msg := getPluginInfoCmd(metadata, model.installer.versionConstraint(), false)()
+	info, ok := msg.(pluginDownloadInfo)
+	if !ok {
+		t.Fatalf("want pluginDownloadInfo, got %T with content %v", msg, msg)
+	}
+	if info.Version.String() != "1.0.0" {
+		t.Fatalf("want the recorded version 1.0.0 to be reinstalled, got %v", info.Version)
+	}
+	model.installer.pluginInfo = info
+	if got := model.installer.entry(); got.Version != "1.0.0" || got.Constraint != "" {
+		t.Fatalf("want the entry to keep version 1.0.0 without a constraint, got %+v", got)
+	}
+}
@@ -1,31 +1,39 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 
+	"github.com/MTVersionManager/mtvm/plugin/call"
 	"github.com/MTVersionManager/mtvm/shared"
-	"github.com/MTVersionManager/mtvmplugin"
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/spf13/cobra"
 )
 
 type removeModel struct {
-	plugin     mtvmplugin.Plugin
+	plugin     *call.Plugin
+	ctx        context.Context
+	cancel     context.CancelFunc
 	version    string
 	pluginName string
 	spinner    spinner.Model
 	done       bool
+	canceled   bool
 }
 
-func removeInitialModel(plugin mtvmplugin.Plugin, version, tool string) removeModel {
+func removeInitialModel(ctx context.Context, plugin *call.Plugin, version, tool string) removeModel {
 	spin := spinner.New()
 	spin.Spinner = spinner.Dot
+	ctx, cancel := context.WithCancel(ctx)
 	return removeModel{
 		plugin:     plugin,
+		ctx:        ctx,
+		cancel:     cancel,
 		version:    version,
 		pluginName: tool,
 		spinner:    spin,
@@ -34,7 +42,7 @@ func removeInitialModel(plugin mtvmplugin.Plugin, version, tool string) removeMo
 }
 
 func (m removeModel) Init() tea.Cmd {
-	return tea.Batch(m.spinner.Tick, remove(m.plugin, m.version, filepath.Join(shared.Configuration.InstallDir, m.pluginName), shared.Configuration.PathDir))
+	return tea.Batch(m.spinner.Tick, remove(m.ctx, m.plugin, m.version, filepath.Join(shared.Configuration.InstallDir, m.pluginName), shared.Configuration.PathDir))
 }
 
 func (m removeModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -44,7 +52,15 @@ func (m removeModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.done = true
 			return m, tea.Quit
 		}
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			m.cancel()
+		}
 	case error:
+		if errors.Is(msg, call.ErrCanceled) {
+			m.canceled = true
+			return m, tea.Quit
+		}
 		log.Fatal(msg)
 	}
 	var cmd tea.Cmd
@@ -53,19 +69,22 @@ func (m removeModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m removeModel) View() string {
+	if m.canceled {
+		return fmt.Sprintf("Canceled removing version %v of %v\n", m.version, m.pluginName)
+	}
 	if m.done {
 		return fmt.Sprintf("%v Successfully removed version %v of %v\n", shared.CheckMark, m.version, m.pluginName)
 	}
 	return fmt.Sprintf("%v Removing version %v of %v\n", m.spinner.View(), m.version, m.pluginName)
 }
 
-func remove(plugin mtvmplugin.Plugin, version string, installDir string, pathDir string) tea.Cmd {
+func remove(ctx context.Context, plugin *call.Plugin, version string, installDir string, pathDir string) tea.Cmd {
 	return func() tea.Msg {
-		currentVer, err := plugin.GetCurrentVersion(installDir, pathDir)
+		currentVer, err := plugin.GetCurrentVersion(ctx, installDir, pathDir)
 		if err != nil {
 			return err
 		}
-		err = plugin.Remove(filepath.Join(installDir, version), pathDir, version == currentVer)
+		err = plugin.Remove(ctx, filepath.Join(installDir, version), pathDir, version == currentVer)
 		if err != nil {
 			return err
 		}
@@ -83,13 +102,14 @@ For example:
 	Args:    cobra.ExactArgs(2),
 	Aliases: []string{"r", "rm"},
 	Run: func(cmd *cobra.Command, args []string) {
-		plugin, err := shared.LoadPlugin(args[0])
+		loaded, err := shared.LoadPlugin(args[0])
 		if err != nil {
 			log.Fatal(err)
 		}
+		plugin := shared.CallPlugin(loaded)
 		version := args[1]
 		if version == "latest" {
-			version, err = plugin.GetLatestVersion()
+			version, err = plugin.GetLatestVersion(cmd.Context())
 			if err != nil {
 				log.Fatal(err)
 			}
@@ -99,10 +119,12 @@ For example:
 			log.Fatal(err)
 		}
 		if installed {
-			p := tea.NewProgram(removeInitialModel(plugin, version, args[0]))
-			if _, err := p.Run(); err != nil {
+			p := tea.NewProgram(removeInitialModel(cmd.Context(), plugin, version, args[0]))
+			if model, err := p.Run(); err != nil {
 				fmt.Printf("Alas, there's been an error: %v", err)
 				os.Exit(1)
+			} else if model, ok := model.(removeModel); ok && model.canceled {
+				os.Exit(1)
 			}
 		} else {
 			fmt.Println("That version is not installed so you can't remove it")
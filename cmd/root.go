@@ -1,8 +1,10 @@
 package cmd
 
 import (
+	"context"
 	"log"
 	"os"
+	"os/signal"
 
 	"github.com/MTVersionManager/mtvm/config"
 	"github.com/MTVersionManager/mtvm/shared"
@@ -22,8 +24,11 @@ var rootCmd = &cobra.Command{
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
+// The context of the commands is canceled on an interrupt, which aborts plugin calls that are running outside of a TUI.
 func Execute() {
-	err := rootCmd.Execute()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	err := rootCmd.ExecuteContext(ctx)
 	if err != nil {
 		os.Exit(1)
 	}
@@ -1,15 +1,17 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/MTVersionManager/mtvm/components/fatalHandler"
 	"github.com/MTVersionManager/mtvm/components/install"
+	"github.com/MTVersionManager/mtvm/plugin/call"
 	"github.com/MTVersionManager/mtvm/shared"
-	"github.com/MTVersionManager/mtvmplugin"
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 
@@ -22,13 +24,13 @@ type useInstallModel struct {
 	spinner    spinner.Model
 	installed  bool
 	used       bool
-	plugin     mtvmplugin.Plugin
+	plugin     *call.Plugin
 	pluginName string
 	version    string
 }
 
-func useInstallInitialModel(plugin mtvmplugin.Plugin, pluginName, version string) useInstallModel {
-	installer := install.New(plugin, pluginName, version)
+func useInstallInitialModel(ctx context.Context, plugin *call.Plugin, pluginName, version string) useInstallModel {
+	installer := install.New(ctx, plugin, pluginName, version)
 	spin := spinner.New()
 	spin.Spinner = spinner.Dot
 	return useInstallModel{
@@ -51,7 +53,14 @@ func (m useInstallModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case install.InstalledMsg:
 		m.installed = true
-		cmds = append(cmds, Use(m.plugin, m.pluginName, m.version))
+		cmds = append(cmds, Use(m.install.Context(), m.plugin, m.pluginName, m.version))
+	case install.CanceledMsg:
+		m.install.Canceled = true
+		return m, tea.Quit
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			return m, m.install.Cancel()
+		}
 	case shared.SuccessMsg:
 		if msg == "use" {
 			m.used = true
@@ -67,6 +76,9 @@ func (m useInstallModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m useInstallModel) View() string {
+	if m.install.Canceled {
+		return "Canceled\n"
+	}
 	if !m.installed {
 		return m.install.View()
 	}
@@ -77,9 +89,9 @@ func (m useInstallModel) View() string {
 	return fmt.Sprintf("%v%v Set version of %v to %v\n", installSuccess, shared.CheckMark, m.pluginName, m.version)
 }
 
-func Use(plugin mtvmplugin.Plugin, tool string, version string) tea.Cmd {
+func Use(ctx context.Context, plugin *call.Plugin, tool string, version string) tea.Cmd {
 	return func() tea.Msg {
-		err := plugin.Use(filepath.Join(shared.Configuration.InstallDir, tool, version), shared.Configuration.PathDir)
+		err := plugin.Use(ctx, filepath.Join(shared.Configuration.InstallDir, tool, version), shared.Configuration.PathDir)
 		if err != nil {
 			return err
 		}
@@ -102,17 +114,18 @@ So if you run go version it will print the version number 1.23.3`,
 		if err != nil {
 			log.Fatal(err)
 		}
-		plugin, err := shared.LoadPlugin(args[0])
+		loaded, err := shared.LoadPlugin(args[0])
 		if err != nil {
 			log.Fatal(err)
 		}
+		plugin := shared.CallPlugin(loaded)
 		switch {
 		case len(args) == 2:
 			version := args[1]
 			fs := afero.NewOsFs()
 			if strings.ToLower(version) == "latest" {
 				var err error
-				version, err = plugin.GetLatestVersion()
+				version, err = plugin.GetLatestVersion(cmd.Context())
 				if err != nil {
 					log.Fatal(err)
 				}
@@ -126,9 +139,16 @@ So if you run go version it will print the version number 1.23.3`,
 				if err != nil {
 					log.Fatal(err)
 				}
-				p := tea.NewProgram(useInstallInitialModel(plugin, args[0], version))
-				if _, err := p.Run(); err != nil {
+				p := tea.NewProgram(useInstallInitialModel(cmd.Context(), plugin, args[0], version))
+				if model, err := p.Run(); err != nil {
 					log.Fatal(err)
+				} else if model, ok := model.(useInstallModel); ok {
+					fatalHandler.Handle(model.install.ErrorHandler)
+					if model.install.Canceled {
+						os.Exit(1)
+					}
+				} else {
+					log.Fatal("unexpected model type")
 				}
 			} else if !versionInstalled {
 				fmt.Println("That version is not installed.")
@@ -138,7 +158,7 @@ So if you run go version it will print the version number 1.23.3`,
 				if err != nil {
 					log.Fatal(err)
 				}
-				err = plugin.Use(filepath.Join(shared.Configuration.InstallDir, args[0], version), shared.Configuration.PathDir)
+				err = plugin.Use(cmd.Context(), filepath.Join(shared.Configuration.InstallDir, args[0], version), shared.Configuration.PathDir)
 				if err != nil {
 					log.Fatal(err)
 				}
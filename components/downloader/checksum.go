@@ -0,0 +1,52 @@
+package downloader
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+var (
+	ErrInvalidChecksum     = errors.New("checksum must be written as algorithm:hex digest")
+	ErrUnsupportedChecksum = errors.New("unsupported checksum algorithm")
+)
+
+// ChecksumError is returned when the checksum of a download doesn't match the expected one
+type ChecksumError struct {
+	Expected string
+	Actual   string
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("checksum mismatch: expected %v, got %v", e.Expected, e.Actual)
+}
+
+var checksumAlgorithms = map[string]func() hash.Hash{
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// ParseChecksum parses a checksum like sha256:<hex digest>, returning a hash for the algorithm and the expected digest
+func ParseChecksum(checksum string) (hash.Hash, []byte, error) {
+	algorithm, digest, ok := strings.Cut(checksum, ":")
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidChecksum, checksum)
+	}
+	newHash, ok := checksumAlgorithms[strings.ToLower(algorithm)]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %v", ErrUnsupportedChecksum, algorithm)
+	}
+	expected, err := hex.DecodeString(digest)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidChecksum, err)
+	}
+	checksumHash := newHash()
+	if len(expected) != checksumHash.Size() {
+		return nil, nil, fmt.Errorf("%w: %v digest must be %v bytes long", ErrInvalidChecksum, algorithm, checksumHash.Size())
+	}
+	return checksumHash, expected, nil
+}
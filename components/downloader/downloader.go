@@ -1,12 +1,17 @@
 package downloader
 
 import (
+	"bytes"
 	"context"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"log"
 	"net/http"
+	"net/url"
+	"os"
 
 	"github.com/MTVersionManager/mtvm/shared"
 	"github.com/charmbracelet/bubbles/spinner"
@@ -16,14 +21,50 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 )
 
+// ErrAllMirrorsFailed is returned if the download failed from its url and from every one of its mirrors
+var ErrAllMirrorsFailed = errors.New("the download failed from every mirror")
+
+// DownloadError is returned if a download fails after it started and there is no mirror left to fall back to.
+// If it was written to a file, the file is deleted.
+type DownloadError struct {
+	Err error
+}
+
+func (e *DownloadError) Error() string {
+	return fmt.Sprintf("download failed: %v", e.Err)
+}
+
+func (e *DownloadError) Unwrap() error {
+	return e.Err
+}
+
 type downloadWriter struct {
 	totalSize       int64
 	downloadedSize  int64
 	file            afero.File
+	filePath        string
+	fs              afero.Fs
 	progressChannel chan float64
-	resp            *http.Response
-	copyDone        chan bool
-	downloadedData  []byte
+	// body is what is downloaded, a http response body or a local file
+	body           io.ReadCloser
+	copyDone       chan bool
+	downloadedData []byte
+	// checksumHash hashes everything that is written if a checksum is expected
+	checksumHash     hash.Hash
+	expectedChecksum []byte
+	checksumErr      error
+	// canceled is true if the download stopped because it was canceled, so it is incomplete
+	canceled bool
+	// urls are the url of the download followed by its mirrors, next is the index of the one to fall back to
+	urls []string
+	next int
+	// servedBy is the url the download comes from
+	servedBy string
+	// errs are the errors of the urls that failed
+	errs []error
+	// err is why the download failed, once it is done
+	err error
+	ctx context.Context
 }
 
 type DownloadStartedMsg struct {
@@ -33,23 +74,85 @@ type DownloadStartedMsg struct {
 
 type DownloadCanceledMsg struct{}
 
+// Start copies the download, starting over from the next mirror if the connection fails while copying
 func (dw *downloadWriter) Start() {
-	var err error
-	if dw.file == nil {
-		_, err = io.Copy(dw, dw.resp.Body)
-	} else {
-		_, err = io.Copy(dw.file, io.TeeReader(dw.resp.Body, dw))
+	for {
+		var err error
+		if dw.file == nil {
+			_, err = io.Copy(dw, dw.body)
+		} else {
+			_, err = io.Copy(dw.file, io.TeeReader(dw.body, dw))
+		}
+		dw.canceled = errors.Is(err, context.Canceled)
+		if err == nil || dw.canceled {
+			break
+		}
+		_ = dw.body.Close()
+		dw.body = nil
+		dw.errs = append(dw.errs, fmt.Errorf("%v: %w", dw.servedBy, err))
+		err = dw.reset()
+		if err == nil {
+			err = dw.openNext()
+		}
+		if err != nil {
+			dw.canceled = errors.Is(err, context.Canceled)
+			if !dw.canceled {
+				dw.err = &DownloadError{Err: err}
+			}
+			break
+		}
 	}
 	// This sends a signal to the update function that it is safe to close the response body
 	dw.copyDone <- true
-	if err != nil && !errors.Is(err, context.Canceled) {
-		fmt.Println("Error from copying")
-		log.Fatal(err)
+}
+
+// openNext opens the next url that can be opened, so the download comes from it.
+// Without mirrors, the error of the url is returned like it is.
+func (dw *downloadWriter) openNext() error {
+	for dw.next < len(dw.urls) {
+		rawUrl := dw.urls[dw.next]
+		dw.next++
+		body, contentLength, err := open(dw.ctx, rawUrl)
+		if err == nil {
+			dw.body = body
+			dw.totalSize = contentLength
+			dw.servedBy = rawUrl
+			return nil
+		}
+		if len(dw.urls) == 1 || errors.Is(err, context.Canceled) {
+			return err
+		}
+		dw.errs = append(dw.errs, fmt.Errorf("%v: %w", rawUrl, err))
+	}
+	if len(dw.urls) == 1 {
+		return dw.errs[0]
 	}
+	return fmt.Errorf("%w: %w", ErrAllMirrorsFailed, errors.Join(dw.errs...))
+}
+
+// reset throws away what was downloaded, so the download can start over from another url
+func (dw *downloadWriter) reset() error {
+	dw.downloadedSize = 0
+	dw.downloadedData = dw.downloadedData[:0]
+	if dw.checksumHash != nil {
+		dw.checksumHash.Reset()
+	}
+	if dw.file == nil {
+		return nil
+	}
+	err := dw.file.Truncate(0)
+	if err != nil {
+		return err
+	}
+	_, err = dw.file.Seek(0, io.SeekStart)
+	return err
 }
 
 func (dw *downloadWriter) Write(p []byte) (int, error) {
 	dw.downloadedSize += int64(len(p))
+	if dw.checksumHash != nil {
+		dw.checksumHash.Write(p)
+	}
 	if dw.file == nil {
 		dw.downloadedData = append(dw.downloadedData, p...)
 	}
@@ -60,7 +163,9 @@ func (dw *downloadWriter) Write(p []byte) (int, error) {
 }
 
 type Model struct {
-	url                string
+	url string
+	// mirrors are tried in order if url fails
+	mirrors            []string
 	downloader         downloadProgress.Model
 	progress           float64
 	writer             *downloadWriter
@@ -79,6 +184,26 @@ func WriteToFs(filePath string, fs afero.Fs) Option {
 			log.Fatal(err)
 		}
 		model.writer.file = file
+		model.writer.filePath = filePath
+		model.writer.fs = fs
+		return model
+	}
+}
+
+// VerifyChecksum makes the download fail with a *ChecksumError if it doesn't match checksum, which is written like sha256:<hex digest>.
+// If the download is written to a file, the file is deleted.
+func VerifyChecksum(checksum string) Option {
+	return func(model Model) Model {
+		model.writer.checksumHash, model.writer.expectedChecksum, model.writer.checksumErr = ParseChecksum(checksum)
+		return model
+	}
+}
+
+// Mirrors makes the download fall back to the mirrors in order if the url fails,
+// because the connection fails, also while downloading, or the response status isn't 200 OK
+func Mirrors(urls ...string) Option {
+	return func(model Model) Model {
+		model.mirrors = urls
 		return model
 	}
 }
@@ -112,36 +237,31 @@ func New(url string, opts ...Option) Model {
 }
 
 func (m Model) Init() tea.Cmd {
-	return tea.Batch(m.startDownload, downloadProgress.WaitForProgress(m.writer.progressChannel), waitForResponseFinish(m.writer.copyDone), m.spinner.Tick)
+	return tea.Batch(m.startDownload, downloadProgress.WaitForProgress(m.writer.progressChannel), waitForResponseFinish(m.writer), m.spinner.Tick)
 }
 
 func (m Model) startDownload() tea.Msg {
-	ctx, cancel := context.WithCancel(context.Background())
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.url, nil)
-	if err != nil {
-		cancel()
-		return err
+	if m.writer.checksumErr != nil {
+		return m.writer.checksumErr
 	}
-	resp, err := http.DefaultClient.Do(req)
+	ctx, cancel := context.WithCancel(context.Background())
+	m.writer.ctx = ctx
+	m.writer.urls = append([]string{m.url}, m.mirrors...)
+	err := m.writer.openNext()
 	if err != nil {
 		cancel()
 		return err
 	}
-	if resp.StatusCode != http.StatusOK {
-		cancel()
-		return fmt.Errorf("%v %v", resp.StatusCode, http.StatusText(resp.StatusCode))
-	}
 	contentLengthKnown := true
-	if resp.ContentLength <= 0 {
-		if resp.ContentLength == -1 {
+	if m.writer.totalSize <= 0 {
+		if m.writer.totalSize == -1 {
 			contentLengthKnown = false
 		} else {
 			cancel()
+			_ = m.writer.body.Close()
 			return errors.New("error when getting content length")
 		}
 	}
-	m.writer.totalSize = resp.ContentLength
-	m.writer.resp = resp
 	if contentLengthKnown && m.writer.file == nil {
 		m.writer.downloadedData = make([]byte, 0, m.writer.totalSize)
 	}
@@ -152,13 +272,84 @@ func (m Model) startDownload() tea.Msg {
 	}
 }
 
-func waitForResponseFinish(doneChan chan bool) tea.Cmd {
+// open opens what is at rawUrl, which can be a http(s) or file url, returning its length or -1 if it is unknown
+func open(ctx context.Context, rawUrl string) (io.ReadCloser, int64, error) {
+	parsedUrl, err := url.Parse(rawUrl)
+	if err != nil {
+		return nil, 0, err
+	}
+	if parsedUrl.Scheme == "file" {
+		file, err := os.Open(shared.FileUrlPath(parsedUrl))
+		if err != nil {
+			return nil, 0, err
+		}
+		info, err := file.Stat()
+		if err != nil {
+			_ = file.Close()
+			return nil, 0, err
+		}
+		size := info.Size()
+		// Empty files are fine, unlike responses that say they are empty
+		if size == 0 {
+			size = -1
+		}
+		return file, size, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawUrl, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return nil, 0, fmt.Errorf("%v %v", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+	return resp.Body, resp.ContentLength, nil
+}
+
+func waitForResponseFinish(dw *downloadWriter) tea.Cmd {
 	return func() tea.Msg {
-		<-doneChan
+		<-dw.copyDone
+		if dw.canceled {
+			return DownloadCanceledMsg{}
+		}
+		if dw.err != nil {
+			return dw.err
+		}
+		if dw.checksumHash != nil {
+			actual := dw.checksumHash.Sum(nil)
+			if !bytes.Equal(actual, dw.expectedChecksum) {
+				return &ChecksumError{
+					Expected: hex.EncodeToString(dw.expectedChecksum),
+					Actual:   hex.EncodeToString(actual),
+				}
+			}
+		}
 		return shared.SuccessMsg("download")
 	}
 }
 
+// finish closes what was downloaded and the file the download was written to
+func (m Model) finish() {
+	m.cancel()
+	// There is no body if falling back to a mirror failed
+	if m.writer.body != nil {
+		err := m.writer.body.Close()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	if m.writer.file != nil {
+		err := m.writer.file.Close()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
 // GetDownloadedData returns the data that was downloaded.
 func (m Model) GetDownloadedData() []byte {
 	return m.writer.downloadedData
@@ -172,17 +363,15 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 		m.cancel = msg.Cancel
 	case shared.SuccessMsg:
 		if msg == "download" {
-			m.cancel()
-			err := m.writer.resp.Body.Close()
+			m.finish()
+		}
+	case *ChecksumError, *DownloadError:
+		m.finish()
+		if m.writer.file != nil {
+			err := m.writer.fs.Remove(m.writer.filePath)
 			if err != nil {
 				log.Fatal(err)
 			}
-			if m.writer.file != nil {
-				err = m.writer.file.Close()
-				if err != nil {
-					log.Fatal(err)
-				}
-			}
 		}
 	case DownloadCanceledMsg:
 		m.Canceled = true
@@ -219,3 +408,8 @@ func (m Model) StopDownload() tea.Cmd {
 func (m Model) GetUrl() string {
 	return m.url
 }
+
+// ServedBy returns the url the download came from once it is done, which is the url of the model or one of its mirrors
+func (m Model) ServedBy() string {
+	return m.writer.servedBy
+}
@@ -1,6 +1,20 @@
 package downloader
 
-import "testing"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MTVersionManager/mtvm/shared"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/afero"
+)
 
 func TestDownloadWriter_Write(t *testing.T) {
 	dw := downloadWriter{
@@ -41,3 +55,202 @@ func TestDownloadWriter_Write(t *testing.T) {
 		t.Fatalf("want 50 bytes of content, got %v bytes of content", len(dw.downloadedData))
 	}
 }
+
+func TestParseChecksum(t *testing.T) {
+	tests := map[string]struct {
+		checksum string
+		wantErr  error
+	}{
+		"sha256":            {checksum: "sha256:" + strings.Repeat("ab", 32)},
+		"uppercase":         {checksum: "SHA256:" + strings.Repeat("AB", 32)},
+		"sha512":            {checksum: "sha512:" + strings.Repeat("ab", 64)},
+		"no algorithm":      {checksum: strings.Repeat("ab", 32), wantErr: ErrInvalidChecksum},
+		"unknown algorithm": {checksum: "md5:" + strings.Repeat("ab", 16), wantErr: ErrUnsupportedChecksum},
+		"wrong length":      {checksum: "sha256:abab", wantErr: ErrInvalidChecksum},
+		"not hex":           {checksum: "sha256:" + strings.Repeat("zz", 32), wantErr: ErrInvalidChecksum},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := ParseChecksum(tt.checksum)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("want error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// download runs the download of the model like the bubbletea program would, returning the updated model and the message it finished with
+func download(t *testing.T, model Model) (Model, tea.Msg) {
+	// The channel is captured because model is reassigned below
+	progressChannel := model.writer.progressChannel
+	go func() {
+		for range progressChannel {
+		}
+	}()
+	t.Cleanup(func() {
+		close(progressChannel)
+	})
+	msg := model.startDownload()
+	if _, ok := msg.(DownloadStartedMsg); !ok {
+		t.Fatalf("want DownloadStartedMsg, got %T with content %v", msg, msg)
+	}
+	model, _ = model.Update(msg)
+	msg = waitForResponseFinish(model.writer)()
+	model, _ = model.Update(msg)
+	return model, msg
+}
+
+func TestDownloadChecksum(t *testing.T) {
+	content := []byte("loremIpsum")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(content)
+	}))
+	defer server.Close()
+	sum := sha256.Sum256(content)
+	tests := map[string]struct {
+		checksum     string
+		wantMismatch bool
+	}{
+		"match":    {checksum: "sha256:" + hex.EncodeToString(sum[:])},
+		"mismatch": {checksum: "sha256:" + strings.Repeat("00", 32), wantMismatch: true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			_, msg := download(t, New(server.URL, WriteToFs("loremIpsum", fs), VerifyChecksum(tt.checksum)))
+			exists, err := afero.Exists(fs, "loremIpsum")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var checksumErr *ChecksumError
+			if tt.wantMismatch {
+				if !errors.As(msg.(error), &checksumErr) {
+					t.Fatalf("want *ChecksumError, got %T with content %v", msg, msg)
+				}
+				if exists {
+					t.Fatal("want file to be deleted after a checksum mismatch")
+				}
+				return
+			}
+			if msg != shared.SuccessMsg("download") {
+				t.Fatalf("want download success, got %T with content %v", msg, msg)
+			}
+			if !exists {
+				t.Fatal("want downloaded file to exist")
+			}
+		})
+	}
+}
+
+func TestDownloadFileUrl(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "loremIpsum.json")
+	if err := os.WriteFile(path, []byte(`{"name": "loremIpsum"}`), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	fileUrl, err := shared.FileUrl(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	model, msg := download(t, New(fileUrl))
+	if msg != shared.SuccessMsg("download") {
+		t.Fatalf("want download success, got %T with content %v", msg, msg)
+	}
+	if string(model.GetDownloadedData()) != `{"name": "loremIpsum"}` {
+		t.Fatalf("want file content to be downloaded, got %v", string(model.GetDownloadedData()))
+	}
+}
+
+func TestDownloadMirrors(t *testing.T) {
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unavailable.Close()
+	working := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("loremIpsum"))
+	}))
+	defer working.Close()
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	tests := map[string]struct {
+		urls         []string
+		wantServedBy string
+	}{
+		"url works":             {urls: []string{working.URL, unavailable.URL}, wantServedBy: working.URL},
+		"status is not ok":      {urls: []string{unavailable.URL, working.URL}, wantServedBy: working.URL},
+		"connection fails":      {urls: []string{closed.URL, unavailable.URL, working.URL}, wantServedBy: working.URL},
+		"every mirror fails":    {urls: []string{unavailable.URL, closed.URL}},
+		"no mirrors, url fails": {urls: []string{unavailable.URL}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			model := New(tt.urls[0], Mirrors(tt.urls[1:]...))
+			if tt.wantServedBy == "" {
+				msg := model.startDownload()
+				err, ok := msg.(error)
+				if !ok {
+					t.Fatalf("want error, got %T with content %v", msg, msg)
+				}
+				if errors.Is(err, ErrAllMirrorsFailed) != (len(tt.urls) > 1) {
+					t.Fatalf("want ErrAllMirrorsFailed only with mirrors, got %v", err)
+				}
+				return
+			}
+			model, msg := download(t, model)
+			if msg != shared.SuccessMsg("download") {
+				t.Fatalf("want download success, got %T with content %v", msg, msg)
+			}
+			if model.ServedBy() != tt.wantServedBy {
+				t.Fatalf("want download served by %v, got %v", tt.wantServedBy, model.ServedBy())
+			}
+			if string(model.GetDownloadedData()) != "loremIpsum" {
+				t.Fatalf("want loremIpsum to be downloaded, got %v", string(model.GetDownloadedData()))
+			}
+		})
+	}
+}
+
+func TestDownloadFallsBackWhenConnectionBreaks(t *testing.T) {
+	// broken promises 100 bytes but closes the connection after sending some of them
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		_, _ = buf.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 100\r\n\r\nbroken")
+		_ = buf.Flush()
+		_ = conn.Close()
+	}))
+	defer broken.Close()
+	working := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("loremIpsum"))
+	}))
+	defer working.Close()
+	sum := sha256.Sum256([]byte("loremIpsum"))
+	checksum := "sha256:" + hex.EncodeToString(sum[:])
+
+	fs := afero.NewMemMapFs()
+	model, msg := download(t, New(broken.URL, WriteToFs("loremIpsum", fs), VerifyChecksum(checksum), Mirrors(working.URL)))
+	if msg != shared.SuccessMsg("download") {
+		t.Fatalf("want download success from the mirror, got %T with content %v", msg, msg)
+	}
+	if model.ServedBy() != working.URL {
+		t.Fatalf("want download served by %v, got %v", working.URL, model.ServedBy())
+	}
+	data, err := afero.ReadFile(fs, "loremIpsum")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "loremIpsum" {
+		t.Fatalf("want only what the mirror sent in the file, got %v", string(data))
+	}
+
+	_, msg = download(t, New(broken.URL, WriteToFs("dolorSitAmet", fs)))
+	var downloadErr *DownloadError
+	if err, ok := msg.(error); !ok || !errors.As(err, &downloadErr) {
+		t.Fatalf("want *DownloadError without a mirror to fall back to, got %T with content %v", msg, msg)
+	}
+	if exists, _ := afero.Exists(fs, "dolorSitAmet"); exists {
+		t.Fatal("want the incomplete file to be deleted")
+	}
+}
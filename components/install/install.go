@@ -1,39 +1,51 @@
 package install
 
 import (
+	"context"
+	"errors"
 	"path/filepath"
 
 	"github.com/MTVersionManager/mtvm/components/downloadProgress"
 	"github.com/MTVersionManager/mtvm/components/fatalHandler"
+	"github.com/MTVersionManager/mtvm/plugin/call"
 	"github.com/MTVersionManager/mtvm/shared"
-	"github.com/MTVersionManager/mtvmplugin"
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 type Model struct {
 	progressChannel chan float64
-	plugin          mtvmplugin.Plugin
+	plugin          *call.Plugin
+	ctx             context.Context
+	cancel          context.CancelFunc
 	installing      bool
 	version         string
 	spinner         spinner.Model
 	pluginName      string
 	downloader      downloadProgress.Model
 	ErrorHandler    fatalHandler.Model
+	Canceled        bool
 }
 
 type InstalledMsg bool
 
-func New(plugin mtvmplugin.Plugin, pluginName, version string) Model {
+type CanceledMsg struct{}
+
+// New creates a model that downloads and installs a version using the plugin.
+// Plugin calls are aborted when ctx is done or Cancel is used.
+func New(ctx context.Context, plugin *call.Plugin, pluginName, version string) Model {
 	progressChannel := make(chan float64)
 	downloader := downloadProgress.New(progressChannel)
 	downloader.Title = "Downloading..."
 	spinnerModel := spinner.New()
 	spinnerModel.Spinner = spinner.Dot
+	ctx, cancel := context.WithCancel(ctx)
 	return Model{
 		progressChannel: progressChannel,
 		version:         version,
 		plugin:          plugin,
+		ctx:             ctx,
+		cancel:          cancel,
 		downloader:      downloader,
 		installing:      true,
 		spinner:         spinnerModel,
@@ -42,12 +54,12 @@ func New(plugin mtvmplugin.Plugin, pluginName, version string) Model {
 }
 
 func (m Model) Init() tea.Cmd {
-	return tea.Batch(downloadProgress.WaitForProgress(m.progressChannel), Download(m.plugin, m.version, m.progressChannel), m.spinner.Tick)
+	return tea.Batch(downloadProgress.WaitForProgress(m.progressChannel), Download(m.ctx, m.plugin, m.version, m.progressChannel), m.spinner.Tick)
 }
 
-func Download(plugin mtvmplugin.Plugin, version string, progressChannel chan float64) tea.Cmd {
+func Download(ctx context.Context, plugin *call.Plugin, version string, progressChannel chan float64) tea.Cmd {
 	return func() tea.Msg {
-		err := plugin.Download(version, progressChannel)
+		err := plugin.Download(ctx, version, progressChannel)
 		if err != nil {
 			return err
 		}
@@ -55,9 +67,9 @@ func Download(plugin mtvmplugin.Plugin, version string, progressChannel chan flo
 	}
 }
 
-func Install(plugin mtvmplugin.Plugin, installDir string, pluginName, version string) tea.Cmd {
+func Install(ctx context.Context, plugin *call.Plugin, installDir string, pluginName, version string) tea.Cmd {
 	return func() tea.Msg {
-		err := plugin.Install(filepath.Join(installDir, pluginName, version))
+		err := plugin.Install(ctx, filepath.Join(installDir, pluginName, version))
 		if err != nil {
 			return err
 		}
@@ -65,16 +77,36 @@ func Install(plugin mtvmplugin.Plugin, installDir string, pluginName, version st
 	}
 }
 
+// Context returns the context plugin calls run under, which is done once the model is canceled
+func (m Model) Context() context.Context {
+	return m.ctx
+}
+
+// Cancel aborts the plugin call that is running
+func (m Model) Cancel() tea.Cmd {
+	return func() tea.Msg {
+		m.cancel()
+		return CanceledMsg{}
+	}
+}
+
 func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 	var cmds []tea.Cmd
 	var cmd tea.Cmd
 	switch msg := msg.(type) {
 	case error:
-		m.ErrorHandler, cmd = m.ErrorHandler.Update(msg)
-		cmds = append(cmds, cmd)
+		// The aborted plugin call returns ErrCanceled, which is not a failure
+		if errors.Is(msg, call.ErrCanceled) {
+			m.Canceled = true
+		} else {
+			m.ErrorHandler, cmd = m.ErrorHandler.Update(msg)
+			cmds = append(cmds, cmd)
+		}
+	case CanceledMsg:
+		m.Canceled = true
 	case downloadProgress.DownloadedMsg:
 		m.installing = false
-		cmds = append(cmds, Install(m.plugin, shared.Configuration.InstallDir, m.pluginName, m.version))
+		cmds = append(cmds, Install(m.ctx, m.plugin, shared.Configuration.InstallDir, m.pluginName, m.version))
 	}
 	m.spinner, cmd = m.spinner.Update(msg)
 	cmds = append(cmds, cmd)
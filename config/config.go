@@ -2,6 +2,7 @@ package config
 
 import (
 	"path/filepath"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -11,6 +12,14 @@ type Config struct {
 	PluginDir  string `json:"pluginDir"`
 	InstallDir string `json:"installDir"`
 	PathDir    string `json:"pathDir"`
+	// PluginTimeout is how long a plugin call may take, 0 means no timeout
+	PluginTimeout time.Duration `json:"pluginTimeout"`
+	// PluginDownloadTimeout is how long downloading or installing a version with a plugin may take, 0 means no timeout
+	PluginDownloadTimeout time.Duration `json:"pluginDownloadTimeout"`
+	// RequireChecksum makes installing a plugin fail if its metadata has no checksum for the download
+	RequireChecksum bool `json:"requireChecksum"`
+	// PluginIndexes are the urls of the indexes plugins are looked up in when installed by name, see the index package
+	PluginIndexes []string `json:"pluginIndexes"`
 }
 
 // isNotExist Checks if the error from viper.ReadInConfig is because of the configuration not existing
@@ -36,6 +45,8 @@ func GetConfig() (Config, error) {
 		return Config{}, err
 	}
 	viper.SetDefault("pathDir", defPathDir)
+	viper.SetDefault("pluginTimeout", time.Minute)
+	viper.SetDefault("pluginDownloadTimeout", 30*time.Minute)
 	viper.SetConfigName("config")
 	viper.SetConfigType("json")
 	viper.AddConfigPath(configDir)
@@ -0,0 +1,66 @@
+// Package api contains what the host and plugins agree on beyond the mtvmplugin.Plugin interface.
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/MTVersionManager/mtvmplugin"
+)
+
+const (
+	// Version is the plugin API version implemented by this version of mtvm
+	Version = 1
+	// MinVersion is the oldest plugin API version this version of mtvm can still load
+	MinVersion = 1
+	// UndeclaredVersion is assumed for plugins that don't declare an API version,
+	// as they were built before plugins could declare one
+	UndeclaredVersion = 1
+)
+
+var ErrIncompatible = errors.New("plugin implements an incompatible API version")
+
+// IncompatibleError is returned when a plugin implements an API version outside of MinVersion to Version
+type IncompatibleError struct {
+	Version int
+}
+
+func (e *IncompatibleError) Error() string {
+	if e.Version > Version {
+		return fmt.Sprintf("plugin implements API version %v, which is newer than the newest version this version of mtvm supports (%v), try updating mtvm", e.Version, Version)
+	}
+	return fmt.Sprintf("plugin implements API version %v, which is older than the oldest version this version of mtvm supports (%v), try updating the plugin", e.Version, MinVersion)
+}
+
+func (e *IncompatibleError) Unwrap() error {
+	return ErrIncompatible
+}
+
+// Versioned is implemented by plugins that declare the API version they implement
+type Versioned interface {
+	APIVersion() int
+}
+
+// Binder is implemented by plugins whose calls can stop by themselves once a context is done,
+// like plugins running in another process or WebAssembly modules, instead of running on after the host gave up on them
+type Binder interface {
+	// Bind returns the plugin with the calls made through it bound to ctx
+	Bind(ctx context.Context) mtvmplugin.Plugin
+}
+
+// VersionOf returns the API version a plugin implements
+func VersionOf(plugin any) int {
+	if versioned, ok := plugin.(Versioned); ok {
+		return versioned.APIVersion()
+	}
+	return UndeclaredVersion
+}
+
+// Check returns an *IncompatibleError if this version of mtvm can't load a plugin implementing the given API version
+func Check(version int) error {
+	if version < MinVersion || version > Version {
+		return &IncompatibleError{Version: version}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"errors"
+	"testing"
+)
+
+type versionedPlugin int
+
+func (v versionedPlugin) APIVersion() int {
+	return int(v)
+}
+
+func TestVersionOf(t *testing.T) {
+	if got := VersionOf(struct{}{}); got != UndeclaredVersion {
+		t.Fatalf("want undeclared version %v, got %v", UndeclaredVersion, got)
+	}
+	if got := VersionOf(versionedPlugin(5)); got != 5 {
+		t.Fatalf("want version 5, got %v", got)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := map[string]struct {
+		version    int
+		wantsError bool
+	}{
+		"current": {version: Version},
+		"too old": {version: MinVersion - 1, wantsError: true},
+		"too new": {version: Version + 1, wantsError: true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := Check(tt.version)
+			if !tt.wantsError {
+				if err != nil {
+					t.Fatalf("want no error, got %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrIncompatible) {
+				t.Fatalf("want error containing ErrIncompatible, got %v", err)
+			}
+			var incompatibleErr *IncompatibleError
+			if !errors.As(err, &incompatibleErr) || incompatibleErr.Version != tt.version {
+				t.Fatalf("want *IncompatibleError with version %v, got %v", tt.version, err)
+			}
+		})
+	}
+}
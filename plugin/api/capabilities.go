@@ -0,0 +1,73 @@
+package api
+
+// Names of the optional capabilities a plugin can support
+const (
+	CapabilityVersionLister = "versionLister"
+	CapabilityEnvProvider   = "envProvider"
+	CapabilityVerifier      = "verifier"
+	CapabilityCanceller     = "canceller"
+)
+
+// AllCapabilities lists every capability name
+var AllCapabilities = []string{CapabilityVersionLister, CapabilityEnvProvider, CapabilityVerifier, CapabilityCanceller}
+
+// VersionLister is implemented by plugins that can list the versions of their tool that are available to install
+type VersionLister interface {
+	ListVersions() ([]string, error)
+}
+
+// EnvProvider is implemented by plugins whose tool needs environment variables set to work, like GOROOT
+type EnvProvider interface {
+	// Env returns the environment variables needed for the version installed in installDir
+	Env(installDir string) (map[string]string, error)
+}
+
+// Verifier is implemented by plugins that can check whether an installed version is intact
+type Verifier interface {
+	Verify(installDir string) error
+}
+
+// Canceller is implemented by plugins that can abort the call that is currently running
+type Canceller interface {
+	Cancel()
+}
+
+// Reporter is implemented by plugins that forward every capability interface to something else,
+// like a plugin running in another process, which may only support some of them
+type Reporter interface {
+	Supports(capability string) bool
+}
+
+// Supports reports whether a plugin supports a capability.
+// Check it before asserting a plugin to a capability interface, as a Reporter implements interfaces it may not support.
+func Supports(plugin any, capability string) bool {
+	var implements bool
+	switch capability {
+	case CapabilityVersionLister:
+		_, implements = plugin.(VersionLister)
+	case CapabilityEnvProvider:
+		_, implements = plugin.(EnvProvider)
+	case CapabilityVerifier:
+		_, implements = plugin.(Verifier)
+	case CapabilityCanceller:
+		_, implements = plugin.(Canceller)
+	}
+	if !implements {
+		return false
+	}
+	if reporter, ok := plugin.(Reporter); ok {
+		return reporter.Supports(capability)
+	}
+	return true
+}
+
+// Capabilities returns the names of the capabilities a plugin supports
+func Capabilities(plugin any) []string {
+	var capabilities []string
+	for _, capability := range AllCapabilities {
+		if Supports(plugin, capability) {
+			capabilities = append(capabilities, capability)
+		}
+	}
+	return capabilities
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"slices"
+	"testing"
+)
+
+type listerPlugin struct{}
+
+func (listerPlugin) ListVersions() ([]string, error) {
+	return nil, nil
+}
+
+// reportingPlugin implements every capability but only supports the ones in supported
+type reportingPlugin struct {
+	listerPlugin
+	supported []string
+}
+
+func (reportingPlugin) Env(string) (map[string]string, error) {
+	return nil, nil
+}
+
+func (reportingPlugin) Verify(string) error {
+	return nil
+}
+
+func (reportingPlugin) Cancel() {}
+
+func (r reportingPlugin) Supports(capability string) bool {
+	return slices.Contains(r.supported, capability)
+}
+
+func TestCapabilities(t *testing.T) {
+	tests := map[string]struct {
+		plugin any
+		want   []string
+	}{
+		"none": {
+			plugin: struct{}{},
+			want:   nil,
+		},
+		"type assertion": {
+			plugin: listerPlugin{},
+			want:   []string{CapabilityVersionLister},
+		},
+		"reporter": {
+			plugin: reportingPlugin{supported: []string{CapabilityVerifier, CapabilityCanceller}},
+			want:   []string{CapabilityVerifier, CapabilityCanceller},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := Capabilities(tt.plugin)
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("want capabilities %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
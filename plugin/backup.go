@@ -0,0 +1,236 @@
+package plugin
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/MTVersionManager/mtvm/shared"
+	"github.com/spf13/afero"
+)
+
+var (
+	ErrNoBackup  = errors.New("there is no previous build to roll back to")
+	ErrEmptyFile = errors.New("the plugin file is empty")
+)
+
+// TempFile is where a plugin file is downloaded or written to before CommitFile moves it into place.
+// It is in the plugin directory, so moving it is atomic.
+func TempFile(pluginName, pluginType string) string {
+	return shared.PluginFile(pluginName, pluginType) + ".partial"
+}
+
+// RemoveTempFile removes the temporary file of an installation that didn't finish, if there is one
+func RemoveTempFile(pluginName, pluginType string, fs afero.Fs) error {
+	err := fs.Remove(TempFile(pluginName, pluginType))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// backupDir is where the previous build of every plugin is kept
+func backupDir() string {
+	return filepath.Join(shared.Configuration.PluginDir, "backup")
+}
+
+// backupFile is where the file of the previous build of a plugin is kept
+func backupFile(pluginName, pluginType string) string {
+	return filepath.Join(backupDir(), filepath.Base(shared.PluginFile(pluginName, pluginType)))
+}
+
+// backupEntryFile is where the entry of the previous build of a plugin is kept, so it can be restored together with the file
+func backupEntryFile(pluginName string) string {
+	return filepath.Join(backupDir(), pluginName+".entry.json")
+}
+
+// CommitFile moves the temporary file of a plugin into place after checking it,
+// keeping the files and entry of the build that was installed before as a backup.
+// The entry of the new build has to be written with UpdateEntries afterwards.
+func CommitFile(pluginName, pluginType string, fs afero.Fs) error {
+	tempFile := TempFile(pluginName, pluginType)
+	info, err := fs.Stat(tempFile)
+	if err != nil {
+		return err
+	}
+	if info.Size() == 0 {
+		_ = fs.Remove(tempFile)
+		return ErrEmptyFile
+	}
+	err = backup(pluginName, fs)
+	if err != nil {
+		return err
+	}
+	err = fs.Rename(tempFile, shared.PluginFile(pluginName, pluginType))
+	if err != nil {
+		return err
+	}
+	return SetupFile(pluginName, pluginType, fs)
+}
+
+// installedTypes returns the plugin types of the files installed for a plugin
+func installedTypes(pluginName string, fs afero.Fs, file func(pluginName, pluginType string) string) ([]string, error) {
+	var types []string
+	for _, pluginType := range shared.PluginTypes {
+		exists, err := afero.Exists(fs, file(pluginName, pluginType))
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			types = append(types, pluginType)
+		}
+	}
+	return types, nil
+}
+
+// entry returns the entry of a plugin, or nil if it has none
+func entry(pluginName string, fs afero.Fs) (*Entry, error) {
+	entries, err := GetEntries(fs)
+	if err != nil {
+		return nil, err
+	}
+	i := slices.IndexFunc(entries, func(entry Entry) bool { return entry.Name == pluginName })
+	if i == -1 {
+		return nil, nil
+	}
+	return &entries[i], nil
+}
+
+// backup moves the installed files of a plugin to the backup directory and copies its entry there, replacing the previous backup.
+// Nothing is done if the plugin is not installed.
+func backup(pluginName string, fs afero.Fs) error {
+	types, err := installedTypes(pluginName, fs, shared.PluginFile)
+	if err != nil || len(types) == 0 {
+		return err
+	}
+	currentEntry, err := entry(pluginName, fs)
+	if err != nil {
+		return err
+	}
+	err = removeBackup(pluginName, fs)
+	if err != nil {
+		return err
+	}
+	err = fs.MkdirAll(backupDir(), 0o777)
+	if err != nil {
+		return err
+	}
+	for _, pluginType := range types {
+		err = fs.Rename(shared.PluginFile(pluginName, pluginType), backupFile(pluginName, pluginType))
+		if err != nil {
+			return err
+		}
+	}
+	if currentEntry == nil {
+		return nil
+	}
+	data, err := json.MarshalIndent(currentEntry, "", "	")
+	if err != nil {
+		return err
+	}
+	return afero.WriteFile(fs, backupEntryFile(pluginName), data, 0o666)
+}
+
+// removeInstalled removes the installed files of a plugin if there are any
+func removeInstalled(pluginName string, fs afero.Fs) error {
+	types, err := installedTypes(pluginName, fs, shared.PluginFile)
+	if err != nil {
+		return err
+	}
+	for _, pluginType := range types {
+		err = fs.Remove(shared.PluginFile(pluginName, pluginType))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeBackup removes the backup of a plugin if there is one
+func removeBackup(pluginName string, fs afero.Fs) error {
+	err := fs.Remove(backupEntryFile(pluginName))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, pluginType := range shared.PluginTypes {
+		err = fs.Remove(backupFile(pluginName, pluginType))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rollback swaps the installed build of a plugin with its backup, restoring both the file and the entry.
+// The build that was installed becomes the backup, so rolling back again undoes the rollback.
+// Returns the entry that was restored, which is nil if the backup had none, and ErrNoBackup if there is no backup.
+func Rollback(pluginName string, fs afero.Fs) (*Entry, error) {
+	return restoreBackup(pluginName, fs, true)
+}
+
+// Restore puts the backup of a plugin back in place of the installed build, which is thrown away instead of becoming the backup.
+// It undoes an installation that failed after CommitFile, so the broken build can't be rolled back to.
+// Returns the entry that was restored, which is nil if the backup had none, and ErrNoBackup if there is no backup.
+func Restore(pluginName string, fs afero.Fs) (*Entry, error) {
+	return restoreBackup(pluginName, fs, false)
+}
+
+// restoreBackup moves the backup of a plugin into place, keeping the installed build as the new backup if keepInstalled is true
+func restoreBackup(pluginName string, fs afero.Fs, keepInstalled bool) (*Entry, error) {
+	backupTypes, err := installedTypes(pluginName, fs, backupFile)
+	if err != nil {
+		return nil, err
+	}
+	if len(backupTypes) == 0 {
+		return nil, ErrNoBackup
+	}
+	var backupEntry *Entry
+	data, err := afero.ReadFile(fs, backupEntryFile(pluginName))
+	if err == nil {
+		backupEntry = &Entry{}
+		err = json.Unmarshal(data, backupEntry)
+		if err != nil {
+			return nil, err
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	// The backup is moved out of the way first, because backing up the installed build replaces it
+	for _, pluginType := range backupTypes {
+		err = fs.Rename(backupFile(pluginName, pluginType), TempFile(pluginName, pluginType))
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = fs.Remove(backupEntryFile(pluginName))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if keepInstalled {
+		err = backup(pluginName, fs)
+	} else {
+		err = removeInstalled(pluginName, fs)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, pluginType := range backupTypes {
+		err = fs.Rename(TempFile(pluginName, pluginType), shared.PluginFile(pluginName, pluginType))
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = RemoveEntry(pluginName, fs)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	if backupEntry != nil {
+		err = UpdateEntries(*backupEntry, fs)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return backupEntry, nil
+}
@@ -0,0 +1,150 @@
+package plugin
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/MTVersionManager/mtvm/shared"
+	"github.com/spf13/afero"
+)
+
+// installBuild installs a build of loremIpsum the way the plugin install command does
+func installBuild(t *testing.T, fs afero.Fs, pluginType, version, content string) {
+	err := WriteFile("loremIpsum", pluginType, []byte(content), fs)
+	if err != nil {
+		t.Fatalf("want no error when writing the temporary file, got %v", err)
+	}
+	err = CommitFile("loremIpsum", pluginType, fs)
+	if err != nil {
+		t.Fatalf("want no error when committing, got %v", err)
+	}
+	err = UpdateEntries(Entry{Name: "loremIpsum", Version: version, MetadataUrl: "https://example.com"}, fs)
+	if err != nil {
+		t.Fatalf("want no error when updating entries, got %v", err)
+	}
+}
+
+// checkInstalledBuild checks that the file and the entry of loremIpsum are the ones of the expected build
+func checkInstalledBuild(t *testing.T, fs afero.Fs, pluginType, version, content string) {
+	data, err := afero.ReadFile(fs, shared.PluginFile("loremIpsum", pluginType))
+	if err != nil {
+		t.Fatalf("want no error when reading the plugin file, got %v", err)
+	}
+	if string(data) != content {
+		t.Fatalf("want plugin file containing %v, got %v", content, string(data))
+	}
+	installedVersion, err := InstalledVersion("loremIpsum", fs)
+	if err != nil {
+		t.Fatalf("want no error when getting the installed version, got %v", err)
+	}
+	if installedVersion != version {
+		t.Fatalf("want entry with version %v, got %v", version, installedVersion)
+	}
+}
+
+func TestCommitFileAndRollback(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	installBuild(t, fs, shared.PluginTypeNative, "1.0.0", "old")
+	installBuild(t, fs, shared.PluginTypeWasm, "2.0.0", "new")
+	checkInstalledBuild(t, fs, shared.PluginTypeWasm, "2.0.0", "new")
+	if exists, _ := afero.Exists(fs, shared.PluginFile("loremIpsum", shared.PluginTypeNative)); exists {
+		t.Fatal("want the file of the old build to be moved to the backup")
+	}
+
+	entry, err := Rollback("loremIpsum", fs)
+	if err != nil {
+		t.Fatalf("want no error when rolling back, got %v", err)
+	}
+	if entry == nil || entry.Version != "1.0.0" {
+		t.Fatalf("want entry of version 1.0.0 to be restored, got %v", entry)
+	}
+	checkInstalledBuild(t, fs, shared.PluginTypeNative, "1.0.0", "old")
+	if exists, _ := afero.Exists(fs, shared.PluginFile("loremIpsum", shared.PluginTypeWasm)); exists {
+		t.Fatal("want the file of the new build to be moved to the backup")
+	}
+
+	// Rolling back again undoes the rollback
+	_, err = Rollback("loremIpsum", fs)
+	if err != nil {
+		t.Fatalf("want no error when rolling back again, got %v", err)
+	}
+	checkInstalledBuild(t, fs, shared.PluginTypeWasm, "2.0.0", "new")
+}
+
+func TestRollbackWithoutBackup(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	installBuild(t, fs, shared.PluginTypeNative, "1.0.0", "old")
+	_, err := Rollback("loremIpsum", fs)
+	if !errors.Is(err, ErrNoBackup) {
+		t.Fatalf("want ErrNoBackup, got %v", err)
+	}
+}
+
+func TestCommitEmptyFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	installBuild(t, fs, shared.PluginTypeNative, "1.0.0", "old")
+	err := WriteFile("loremIpsum", shared.PluginTypeNative, nil, fs)
+	if err != nil {
+		t.Fatalf("want no error when writing the temporary file, got %v", err)
+	}
+	err = CommitFile("loremIpsum", shared.PluginTypeNative, fs)
+	if !errors.Is(err, ErrEmptyFile) {
+		t.Fatalf("want ErrEmptyFile, got %v", err)
+	}
+	checkInstalledBuild(t, fs, shared.PluginTypeNative, "1.0.0", "old")
+	if exists, _ := afero.Exists(fs, TempFile("loremIpsum", shared.PluginTypeNative)); exists {
+		t.Fatal("want empty temporary file to be removed")
+	}
+}
+
+func TestRestoreDropsInstalledBuild(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	installBuild(t, fs, shared.PluginTypeNative, "1.0.0", "old")
+	installBuild(t, fs, shared.PluginTypeNative, "2.0.0", "good")
+	// A broken build that is committed, but whose installation fails before its entry is written
+	err := WriteFile("loremIpsum", shared.PluginTypeWasm, []byte("broken"), fs)
+	if err != nil {
+		t.Fatalf("want no error when writing the temporary file, got %v", err)
+	}
+	err = CommitFile("loremIpsum", shared.PluginTypeWasm, fs)
+	if err != nil {
+		t.Fatalf("want no error when committing, got %v", err)
+	}
+
+	entry, err := Restore("loremIpsum", fs)
+	if err != nil {
+		t.Fatalf("want no error when restoring, got %v", err)
+	}
+	if entry == nil || entry.Version != "2.0.0" {
+		t.Fatalf("want entry of version 2.0.0 to be restored, got %v", entry)
+	}
+	checkInstalledBuild(t, fs, shared.PluginTypeNative, "2.0.0", "good")
+	if exists, _ := afero.Exists(fs, shared.PluginFile("loremIpsum", shared.PluginTypeWasm)); exists {
+		t.Fatal("want the file of the broken build to be removed")
+	}
+	_, err = Rollback("loremIpsum", fs)
+	if !errors.Is(err, ErrNoBackup) {
+		t.Fatalf("want no backup of the broken build to roll back to, got %v", err)
+	}
+}
+
+func TestRemoveDeletesBackup(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	installBuild(t, fs, shared.PluginTypeNative, "1.0.0", "old")
+	installBuild(t, fs, shared.PluginTypeNative, "2.0.0", "new")
+	err := Remove("loremIpsum", fs)
+	if err != nil {
+		t.Fatalf("want no error when removing, got %v", err)
+	}
+	err = RemoveEntry("loremIpsum", fs)
+	if err != nil {
+		t.Fatalf("want no error when removing the entry, got %v", err)
+	}
+	_, err = Rollback("loremIpsum", fs)
+	if !errors.Is(err, ErrNoBackup) {
+		t.Fatalf("want a removed plugin to have no backup to roll back to, got %v", err)
+	}
+	if exists, _ := afero.Exists(fs, backupEntryFile("loremIpsum")); exists {
+		t.Fatal("want the backup entry to be removed")
+	}
+}
@@ -0,0 +1,50 @@
+// Package builtin is the registry of plugins compiled into mtvm, which work without installing anything.
+package builtin
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/MTVersionManager/mtvm/plugin/builtin/golang"
+	"github.com/MTVersionManager/mtvmplugin"
+)
+
+// Factory creates a new instance of a built-in plugin
+type Factory func() mtvmplugin.Plugin
+
+var (
+	registry = map[string]Factory{
+		golang.Name: func() mtvmplugin.Plugin { return golang.New() },
+	}
+	registryMu sync.RWMutex
+)
+
+// Register adds a built-in plugin, replacing any built-in plugin with the same name
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[name] = factory
+}
+
+// Lookup creates the built-in plugin with the given name, the bool is false if there is none
+func Lookup(name string) (mtvmplugin.Plugin, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	factory, ok := registry[name]
+	if !ok {
+		return nil, false
+	}
+	return factory(), true
+}
+
+// Names returns the names of the built-in plugins in alphabetical order
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
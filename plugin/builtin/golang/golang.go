@@ -0,0 +1,232 @@
+// Package golang implements the built-in plugin for the Go toolchain.
+// Versions are looked up in the download index served by go.dev, which lists every release with its files and their checksums.
+package golang
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/MTVersionManager/mtvm/plugin/api"
+	"github.com/MTVersionManager/mtvm/plugin/manifest"
+)
+
+// Name is the name the plugin is registered under
+const Name = "go"
+
+const (
+	// DefaultIndexUrl is the download index of every Go release
+	DefaultIndexUrl = "https://go.dev/dl/?mode=json&include=all"
+	// DefaultDownloadUrl is where the files listed in the index are downloaded from
+	DefaultDownloadUrl = "https://go.dev/dl/"
+)
+
+var (
+	ErrVersionNotFound  = errors.New("version not found in the Go download index")
+	ErrNoDownload       = errors.New("no download of this version for your system")
+	ErrChecksumMismatch = errors.New("checksum of the downloaded file does not match the download index")
+)
+
+// Release is a release in the download index
+type Release struct {
+	Version string `json:"version"`
+	Stable  bool   `json:"stable"`
+	Files   []File `json:"files"`
+}
+
+// File is a downloadable file of a release
+type File struct {
+	Filename string `json:"filename"`
+	OS       string `json:"os"`
+	Arch     string `json:"arch"`
+	Version  string `json:"version"`
+	Sha256   string `json:"sha256"`
+	Size     int64  `json:"size"`
+	Kind     string `json:"kind"`
+}
+
+// Plugin implements mtvmplugin.Plugin for Go.
+// Using, removing and finding the current version work the same as for a manifest with the go and gofmt binaries, so they are handled by the embedded manifest.Plugin.
+type Plugin struct {
+	*manifest.Plugin
+	IndexUrl    string
+	DownloadUrl string
+	Client      *http.Client
+	// downloadedFile is the temporary file Download wrote the archive to
+	downloadedFile string
+	archive        string
+}
+
+func New() *Plugin {
+	binaries := []string{"bin/go", "bin/gofmt"}
+	if runtime.GOOS == "windows" {
+		binaries = []string{"bin/go.exe", "bin/gofmt.exe"}
+	}
+	return &Plugin{
+		Plugin: manifest.New(manifest.Manifest{
+			Name:     Name,
+			Binaries: binaries,
+		}),
+		IndexUrl:    DefaultIndexUrl,
+		DownloadUrl: DefaultDownloadUrl,
+		Client:      http.DefaultClient,
+	}
+}
+
+// APIVersion always returns api.Version, as built-in plugins are compiled into mtvm
+func (p *Plugin) APIVersion() int {
+	return api.Version
+}
+
+func (p *Plugin) Supports(capability string) bool {
+	switch capability {
+	case api.CapabilityVersionLister, api.CapabilityEnvProvider, api.CapabilityVerifier:
+		return true
+	default:
+		return false
+	}
+}
+
+// Index fetches the download index, which lists the newest releases first
+func (p *Plugin) Index() ([]Release, error) {
+	resp, err := p.Client.Get(p.IndexUrl)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%v %v", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+	var releases []Release
+	err = json.NewDecoder(resp.Body).Decode(&releases)
+	if err != nil {
+		return nil, err
+	}
+	return releases, nil
+}
+
+// ListVersions returns the stable versions that can be downloaded for this system, newest first
+func (p *Plugin) ListVersions() ([]string, error) {
+	releases, err := p.Index()
+	if err != nil {
+		return nil, err
+	}
+	var versions []string
+	for _, release := range releases {
+		if release.Stable && release.archive() != nil {
+			versions = append(versions, strings.TrimPrefix(release.Version, "go"))
+		}
+	}
+	return versions, nil
+}
+
+func (p *Plugin) GetLatestVersion() (string, error) {
+	versions, err := p.ListVersions()
+	if err != nil {
+		return "", err
+	}
+	if len(versions) == 0 {
+		return "", ErrNoDownload
+	}
+	return versions[0], nil
+}
+
+// archive returns the archive of the release for this system, or nil if there is none
+func (r Release) archive() *File {
+	for i, file := range r.Files {
+		if file.Kind == "archive" && file.OS == runtime.GOOS && file.Arch == runtime.GOARCH {
+			return &r.Files[i]
+		}
+	}
+	return nil
+}
+
+// Download downloads the archive of a version and checks it against the checksum in the index
+func (p *Plugin) Download(version string, progress chan float64) error {
+	releases, err := p.Index()
+	if err != nil {
+		return err
+	}
+	var file *File
+	for _, release := range releases {
+		if release.Version == "go"+version {
+			file = release.archive()
+			if file == nil {
+				return ErrNoDownload
+			}
+			break
+		}
+	}
+	if file == nil {
+		return fmt.Errorf("%w: %v", ErrVersionNotFound, version)
+	}
+	resp, err := p.Client.Get(p.DownloadUrl + file.Filename)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%v %v", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+	temp, err := os.CreateTemp("", "mtvm-go-*")
+	if err != nil {
+		return err
+	}
+	defer temp.Close()
+	hash := sha256.New()
+	totalSize := resp.ContentLength
+	if totalSize <= 0 {
+		totalSize = file.Size
+	}
+	_, err = io.Copy(io.MultiWriter(temp, hash), io.TeeReader(resp.Body, &manifest.ProgressWriter{
+		TotalSize: totalSize,
+		Progress:  progress,
+	}))
+	if err == nil && hex.EncodeToString(hash.Sum(nil)) != file.Sha256 {
+		err = ErrChecksumMismatch
+	}
+	if err != nil {
+		_ = os.Remove(temp.Name())
+		return err
+	}
+	p.downloadedFile = temp.Name()
+	p.archive = manifest.ArchiveTarGz
+	if strings.HasSuffix(file.Filename, ".zip") {
+		p.archive = manifest.ArchiveZip
+	}
+	progress <- 1
+	return nil
+}
+
+// Install extracts the downloaded archive, which has everything inside of a go directory
+func (p *Plugin) Install(installDir string) error {
+	if p.downloadedFile == "" {
+		return manifest.ErrNotDownloaded
+	}
+	defer func() {
+		_ = os.Remove(p.downloadedFile)
+		p.downloadedFile = ""
+	}()
+	err := os.MkdirAll(installDir, 0o755)
+	if err != nil {
+		return err
+	}
+	err = manifest.Extract(p.archive, p.downloadedFile, installDir, 1)
+	if err != nil {
+		_ = os.RemoveAll(installDir)
+	}
+	return err
+}
+
+// Env sets GOROOT to the install directory
+func (p *Plugin) Env(installDir string) (map[string]string, error) {
+	return map[string]string{"GOROOT": filepath.Clean(installDir)}, nil
+}
@@ -0,0 +1,137 @@
+package golang
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/MTVersionManager/mtvm/plugin/manifest/archivetest"
+)
+
+// newTestServer serves a download index with the given releases and the archive for every file in it
+func newTestServer(t *testing.T, releases []Release, archive []byte) *Plugin {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/index" {
+			_ = json.NewEncoder(w).Encode(releases)
+			return
+		}
+		_, _ = w.Write(archive)
+	}))
+	t.Cleanup(server.Close)
+	plugin := New()
+	plugin.IndexUrl = server.URL + "/index"
+	plugin.DownloadUrl = server.URL + "/dl/"
+	plugin.Client = server.Client()
+	return plugin
+}
+
+func testRelease(version string, stable bool, sha256 string) Release {
+	return Release{
+		Version: "go" + version,
+		Stable:  stable,
+		Files: []File{
+			{Filename: "go" + version + ".src.tar.gz", Kind: "source", Sha256: sha256},
+			{Filename: "go" + version + ".loremIpsum-amd64.tar.gz", OS: "loremIpsum", Arch: "amd64", Kind: "archive", Sha256: sha256},
+			{Filename: "go" + version + "." + runtime.GOOS + "-" + runtime.GOARCH + ".tar.gz", OS: runtime.GOOS, Arch: runtime.GOARCH, Kind: "archive", Sha256: sha256},
+		},
+	}
+}
+
+func TestGetLatestVersionSkipsUnstable(t *testing.T) {
+	plugin := newTestServer(t, []Release{
+		testRelease("1.24rc1", false, ""),
+		testRelease("1.23.3", true, ""),
+		testRelease("1.22.9", true, ""),
+	}, nil)
+	version, err := plugin.GetLatestVersion()
+	if err != nil {
+		t.Fatalf("want no error, got %v", err)
+	}
+	if version != "1.23.3" {
+		t.Fatalf("want version 1.23.3, got %v", version)
+	}
+	versions, err := plugin.ListVersions()
+	if err != nil {
+		t.Fatalf("want no error when listing versions, got %v", err)
+	}
+	if len(versions) != 2 || versions[1] != "1.22.9" {
+		t.Fatalf("want versions [1.23.3 1.22.9], got %v", versions)
+	}
+}
+
+func TestInstallUseRemove(t *testing.T) {
+	archive := archivetest.TarGz(t, map[string]string{
+		"go/bin/go":    "#!/bin/sh\n",
+		"go/bin/gofmt": "#!/bin/sh\n",
+		"go/VERSION":   "go1.23.3",
+	})
+	checksum := sha256.Sum256(archive)
+	plugin := newTestServer(t, []Release{testRelease("1.23.3", true, hex.EncodeToString(checksum[:]))}, archive)
+	toolDir := t.TempDir()
+	pathDir := t.TempDir()
+	installDir := filepath.Join(toolDir, "1.23.3")
+	if err := download(plugin, "1.23.3"); err != nil {
+		t.Fatalf("want no error when downloading, got %v", err)
+	}
+	if err := plugin.Install(installDir); err != nil {
+		t.Fatalf("want no error when installing, got %v", err)
+	}
+	if err := plugin.Verify(installDir); err != nil {
+		t.Fatalf("want installation to be valid, got %v", err)
+	}
+	if err := plugin.Use(installDir, pathDir); err != nil {
+		t.Fatalf("want no error when using, got %v", err)
+	}
+	version, err := plugin.GetCurrentVersion(toolDir, pathDir)
+	if err != nil {
+		t.Fatalf("want no error when getting current version, got %v", err)
+	}
+	if version != "1.23.3" {
+		t.Fatalf("want current version 1.23.3, got '%v'", version)
+	}
+	if err := plugin.Remove(installDir, pathDir, true); err != nil {
+		t.Fatalf("want no error when removing, got %v", err)
+	}
+	if _, err := os.Stat(installDir); !os.IsNotExist(err) {
+		t.Fatalf("want install directory to be removed, got %v (stat)", err)
+	}
+}
+
+func TestDownloadChecksumMismatch(t *testing.T) {
+	plugin := newTestServer(t, []Release{testRelease("1.23.3", true, "0000")}, []byte("loremIpsum"))
+	err := download(plugin, "1.23.3")
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("want ErrChecksumMismatch, got %v", err)
+	}
+}
+
+func TestDownloadUnknownVersion(t *testing.T) {
+	plugin := newTestServer(t, []Release{testRelease("1.23.3", true, "")}, nil)
+	err := download(plugin, "0.0.0")
+	if !errors.Is(err, ErrVersionNotFound) {
+		t.Fatalf("want ErrVersionNotFound, got %v", err)
+	}
+}
+
+// download runs Download, reading its progress until it is done
+func download(plugin *Plugin, version string) error {
+	progress := make(chan float64)
+	errChannel := make(chan error)
+	go func() {
+		errChannel <- plugin.Download(version, progress)
+	}()
+	for {
+		select {
+		case <-progress:
+		case err := <-errChannel:
+			return err
+		}
+	}
+}
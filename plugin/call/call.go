@@ -0,0 +1,155 @@
+// Package call wraps plugins so every call runs under a context.
+// Calls time out, can be canceled, and panics inside the plugin are turned into errors instead of crashing mtvm.
+package call
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/MTVersionManager/mtvm/plugin/api"
+	"github.com/MTVersionManager/mtvmplugin"
+)
+
+var (
+	ErrTimeout  = errors.New("plugin call timed out")
+	ErrCanceled = errors.New("plugin call was canceled")
+)
+
+// PanicError is returned when a plugin panics during a call
+type PanicError struct {
+	Method string
+	Value  any
+	Stack  []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("plugin panicked during %v: %v", e.Method, e.Value)
+}
+
+// Plugin runs the methods of a mtvmplugin.Plugin under a context
+type Plugin struct {
+	plugin          mtvmplugin.Plugin
+	timeout         time.Duration
+	downloadTimeout time.Duration
+}
+
+type Option func(*Plugin)
+
+// WithTimeout sets the timeout of every call except Download and Install, 0 means no timeout
+func WithTimeout(timeout time.Duration) Option {
+	return func(p *Plugin) {
+		p.timeout = timeout
+	}
+}
+
+// WithDownloadTimeout sets the timeout of Download and Install, which take longer than other calls, 0 means no timeout
+func WithDownloadTimeout(timeout time.Duration) Option {
+	return func(p *Plugin) {
+		p.downloadTimeout = timeout
+	}
+}
+
+func Wrap(plugin mtvmplugin.Plugin, opts ...Option) *Plugin {
+	p := &Plugin{plugin: plugin}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Unwrap returns the wrapped plugin, for example to check its capabilities
+func (p *Plugin) Unwrap() mtvmplugin.Plugin {
+	return p.plugin
+}
+
+// run calls f with the plugin in the background and waits until it returns or ctx is done.
+// If ctx is done first, plugins supporting api.CapabilityCanceller are told to abort the call,
+// and an error wrapping ErrTimeout or ErrCanceled is returned.
+// Plugins implementing api.Binder get the call bound to ctx, so it doesn't keep running in the background.
+func run[T any](ctx context.Context, p *Plugin, method string, timeout time.Duration, f func(plugin mtvmplugin.Plugin) (T, error)) (T, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	plugin := p.plugin
+	if binder, ok := plugin.(api.Binder); ok {
+		plugin = binder.Bind(ctx)
+	}
+	type result struct {
+		value T
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				done <- result{err: &PanicError{
+					Method: method,
+					Value:  recovered,
+					Stack:  debug.Stack(),
+				}}
+			}
+		}()
+		value, err := f(plugin)
+		done <- result{value: value, err: err}
+	}()
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-ctx.Done():
+		if api.Supports(p.plugin, api.CapabilityCanceller) {
+			p.plugin.(api.Canceller).Cancel()
+		}
+		var zero T
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return zero, fmt.Errorf("%w: %v took longer than %v", ErrTimeout, method, timeout)
+		}
+		return zero, fmt.Errorf("%w: %v", ErrCanceled, method)
+	}
+}
+
+// runErr is run for methods that only return an error
+func runErr(ctx context.Context, p *Plugin, method string, timeout time.Duration, f func(plugin mtvmplugin.Plugin) error) error {
+	_, err := run(ctx, p, method, timeout, func(plugin mtvmplugin.Plugin) (struct{}, error) {
+		return struct{}{}, f(plugin)
+	})
+	return err
+}
+
+func (p *Plugin) Download(ctx context.Context, version string, progress chan float64) error {
+	return runErr(ctx, p, "Download", p.downloadTimeout, func(plugin mtvmplugin.Plugin) error {
+		return plugin.Download(version, progress)
+	})
+}
+
+func (p *Plugin) Install(ctx context.Context, installDir string) error {
+	return runErr(ctx, p, "Install", p.downloadTimeout, func(plugin mtvmplugin.Plugin) error {
+		return plugin.Install(installDir)
+	})
+}
+
+func (p *Plugin) Use(ctx context.Context, installDir string, pathDir string) error {
+	return runErr(ctx, p, "Use", p.timeout, func(plugin mtvmplugin.Plugin) error {
+		return plugin.Use(installDir, pathDir)
+	})
+}
+
+func (p *Plugin) Remove(ctx context.Context, installDir string, pathDir string, inUse bool) error {
+	return runErr(ctx, p, "Remove", p.timeout, func(plugin mtvmplugin.Plugin) error {
+		return plugin.Remove(installDir, pathDir, inUse)
+	})
+}
+
+func (p *Plugin) GetCurrentVersion(ctx context.Context, installDir string, pathDir string) (string, error) {
+	return run(ctx, p, "GetCurrentVersion", p.timeout, func(plugin mtvmplugin.Plugin) (string, error) {
+		return plugin.GetCurrentVersion(installDir, pathDir)
+	})
+}
+
+func (p *Plugin) GetLatestVersion(ctx context.Context) (string, error) {
+	return run(ctx, p, "GetLatestVersion", p.timeout, mtvmplugin.Plugin.GetLatestVersion)
+}
@@ -0,0 +1,125 @@
+package call
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/MTVersionManager/mtvmplugin"
+)
+
+// testPlugin implements mtvmplugin.Plugin, with GetLatestVersion doing whatever latest does
+type testPlugin struct {
+	latest   func() (string, error)
+	canceled chan struct{}
+}
+
+func (p *testPlugin) Download(string, chan float64) error              { return nil }
+func (p *testPlugin) Install(string) error                             { return nil }
+func (p *testPlugin) Use(string, string) error                         { return nil }
+func (p *testPlugin) Remove(string, string, bool) error                { return nil }
+func (p *testPlugin) GetCurrentVersion(string, string) (string, error) { return "", nil }
+func (p *testPlugin) GetLatestVersion() (string, error)                { return p.latest() }
+
+// cancellablePlugin also implements api.Canceller
+type cancellablePlugin struct {
+	testPlugin
+}
+
+func (p *cancellablePlugin) Cancel() {
+	close(p.canceled)
+}
+
+// boundPlugin also implements api.Binder, and its GetLatestVersion runs until the context it is bound to is done
+type boundPlugin struct {
+	testPlugin
+	ctx      context.Context
+	returned chan struct{}
+}
+
+func (p *boundPlugin) Bind(ctx context.Context) mtvmplugin.Plugin {
+	return &boundPlugin{ctx: ctx, returned: p.returned}
+}
+
+func (p *boundPlugin) GetLatestVersion() (string, error) {
+	<-p.ctx.Done()
+	close(p.returned)
+	return "", p.ctx.Err()
+}
+
+func TestRunReturnsResult(t *testing.T) {
+	wrapped := Wrap(&testPlugin{latest: func() (string, error) {
+		return "1.2.3", nil
+	}}, WithTimeout(time.Second))
+	version, err := wrapped.GetLatestVersion(context.Background())
+	if err != nil {
+		t.Fatalf("want no error, got %v", err)
+	}
+	if version != "1.2.3" {
+		t.Fatalf("want version 1.2.3, got %v", version)
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	hang := make(chan struct{})
+	defer close(hang)
+	wrapped := Wrap(&testPlugin{latest: func() (string, error) {
+		<-hang
+		return "", nil
+	}}, WithTimeout(10*time.Millisecond))
+	_, err := wrapped.GetLatestVersion(context.Background())
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("want error containing ErrTimeout, got %v", err)
+	}
+}
+
+func TestRunCancel(t *testing.T) {
+	hang := make(chan struct{})
+	defer close(hang)
+	plugin := &cancellablePlugin{testPlugin{
+		latest: func() (string, error) {
+			<-hang
+			return "", nil
+		},
+		canceled: make(chan struct{}),
+	}}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := Wrap(plugin).GetLatestVersion(ctx)
+	if !errors.Is(err, ErrCanceled) {
+		t.Fatalf("want error containing ErrCanceled, got %v", err)
+	}
+	select {
+	case <-plugin.canceled:
+	case <-time.After(time.Second):
+		t.Fatal("want plugin to be told to cancel")
+	}
+}
+
+func TestRunPanic(t *testing.T) {
+	wrapped := Wrap(&testPlugin{latest: func() (string, error) {
+		panic("loremIpsum")
+	}})
+	_, err := wrapped.GetLatestVersion(context.Background())
+	var panicErr *PanicError
+	if !errors.As(err, &panicErr) {
+		t.Fatalf("want *PanicError, got %T with content %v", err, err)
+	}
+	if panicErr.Method != "GetLatestVersion" || panicErr.Value != "loremIpsum" {
+		t.Fatalf("want panic in GetLatestVersion with value loremIpsum, got %v", panicErr)
+	}
+}
+
+func TestRunBindsContext(t *testing.T) {
+	plugin := &boundPlugin{ctx: context.Background(), returned: make(chan struct{})}
+	_, err := Wrap(plugin, WithTimeout(10*time.Millisecond)).GetLatestVersion(context.Background())
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("want error containing ErrTimeout, got %v", err)
+	}
+	select {
+	case <-plugin.returned:
+	case <-time.After(time.Second):
+		t.Fatal("want the call to stop once it timed out")
+	}
+}
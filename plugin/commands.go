@@ -68,3 +68,25 @@ func RemoveCmd(pluginName string, fs afero.Fs) tea.Cmd {
 		return shared.SuccessMsg("Remove")
 	}
 }
+
+// WriteFileCmd returns an error on failure and a shared.SuccessMsg with contents "WriteFile" on success
+func WriteFileCmd(pluginName, pluginType string, data []byte, fs afero.Fs) tea.Cmd {
+	return func() tea.Msg {
+		err := WriteFile(pluginName, pluginType, data, fs)
+		if err != nil {
+			return err
+		}
+		return shared.SuccessMsg("WriteFile")
+	}
+}
+
+// CommitFileCmd returns an error on failure and a shared.SuccessMsg with contents "CommitFile" on success
+func CommitFileCmd(pluginName, pluginType string, fs afero.Fs) tea.Cmd {
+	return func() tea.Msg {
+		err := CommitFile(pluginName, pluginType, fs)
+		if err != nil {
+			return err
+		}
+		return shared.SuccessMsg("CommitFile")
+	}
+}
@@ -1,5 +1,18 @@
 package plugin
 
-import "errors"
+import (
+	"errors"
 
-var ErrNotFound = errors.New("plugin not found")
+	"github.com/MTVersionManager/mtvm/shared"
+)
+
+// ErrNotFound is shared.ErrPluginNotFound, so errors returned by shared.LoadPlugin can be checked against it too
+var ErrNotFound = shared.ErrPluginNotFound
+
+var ErrIncompatibleMtvm = errors.New("plugin does not support this version of mtvm")
+
+// ErrInvalidDownloadUrl is returned for download urls that are neither http(s) urls nor local files next to local metadata
+var ErrInvalidDownloadUrl = errors.New("invalid download url")
+
+// ErrNoMatchingRelease is returned if none of the releases in the metadata matches the requested version
+var ErrNoMatchingRelease = errors.New("no release matches the requested version")
@@ -0,0 +1,37 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Export returns a file plugin import can install the plugins of the entries from on another system.
+// It has the format of plugins.json, but leaves out the checksums of the installed files and which mirrors worked on this system.
+func Export(entries []Entry) ([]byte, error) {
+	exported := make([]Entry, len(entries))
+	for i, entry := range entries {
+		entry.Checksum = ""
+		entry.MetadataMirror = ""
+		entry.Mirror = ""
+		exported[i] = entry
+	}
+	return json.MarshalIndent(entriesDocument{SchemaVersion: SchemaVersion, Plugins: exported}, "", "	")
+}
+
+// ParseExport parses a file written by Export, or a plugins.json file, and validates the entries in it
+func ParseExport(data []byte) ([]Entry, error) {
+	entries, _, err := parseEntries(data)
+	if err != nil {
+		return nil, err
+	}
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	for i, entry := range entries {
+		err = validate.Struct(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid plugin %v: %w", i+1, err)
+		}
+	}
+	return entries, nil
+}
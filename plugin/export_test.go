@@ -0,0 +1,58 @@
+package plugin
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExportAndParseExport(t *testing.T) {
+	entries := []Entry{
+		{Name: "loremIpsum", Version: "1.0.0", MetadataUrl: "https://example.com/loremIpsum.json", Checksum: "sha256:" + strings.Repeat("ab", 32)},
+		{Name: "dolorSitAmet", Version: "2.0.0", MetadataUrl: "https://example.com/dolorSitAmet.json", Constraint: "^2",
+			MetadataMirrors: []string{"https://mirror.example.org/dolorSitAmet.json"}, MetadataMirror: "https://mirror.example.org/dolorSitAmet.json", Mirror: "https://mirror.example.org/dolorSitAmet.so"},
+	}
+	data, err := Export(entries)
+	if err != nil {
+		t.Fatalf("want no error when exporting, got %v", err)
+	}
+	if strings.Contains(string(data), "checksum") {
+		t.Fatalf("want checksums to be left out, got\n%v", string(data))
+	}
+	imported, err := ParseExport(data)
+	if err != nil {
+		t.Fatalf("want no error when parsing, got %v", err)
+	}
+	if len(imported) != 2 || imported[0].Checksum != "" || imported[1].Constraint != "^2" {
+		t.Fatalf("want the entries without checksums, got %v", imported)
+	}
+	if len(imported[1].MetadataMirrors) != 1 || imported[1].MetadataMirror != "" || imported[1].Mirror != "" {
+		t.Fatalf("want the mirrors without the ones that worked on this system, got %+v", imported[1])
+	}
+}
+
+func TestParseExportInvalid(t *testing.T) {
+	tests := map[string]string{
+		"missing version": `{"schemaVersion": 1, "plugins": [{"name": "loremIpsum", "metadataUrl": "https://example.com"}]}`,
+		"invalid url":     `{"schemaVersion": 1, "plugins": [{"name": "loremIpsum", "version": "1.0.0", "metadataUrl": "loremIpsum"}]}`,
+		"not json":        `loremIpsum`,
+		"invalid mirror":  `{"schemaVersion": 1, "plugins": [{"name": "loremIpsum", "version": "1.0.0", "metadataUrl": "https://example.com", "metadataMirrors": ["loremIpsum"]}]}`,
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseExport([]byte(data))
+			if err == nil {
+				t.Fatal("want error, got nil")
+			}
+		})
+	}
+}
+
+func TestParseExportPluginsJson(t *testing.T) {
+	entries, err := ParseExport([]byte(twoEntryJson))
+	if err != nil {
+		t.Fatalf("want plugins.json in the old format to be accepted, got %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("want 2 entries, got %v", entries)
+	}
+}
@@ -0,0 +1,113 @@
+// Package index resolves plugin names to metadata urls using plugin indexes.
+//
+// An index is a JSON document served over http(s) or read from a file:// url, like:
+//
+//	{"plugins": {"go": {"metadataUrl": "https://example.com/go.json", "description": "The Go toolchain"}}}
+//
+// Metadata urls can be relative to the url of the index.
+package index
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/MTVersionManager/mtvm/shared"
+	"github.com/go-playground/validator/v10"
+)
+
+var (
+	ErrNotInIndex     = errors.New("plugin not found in any index")
+	ErrNoIndexes      = errors.New("no plugin indexes are configured")
+	ErrUnsupportedUrl = errors.New("index urls must use http, https or file")
+	ErrIndexTooLarge  = errors.New("index is larger than the maximum index size")
+)
+
+// maxIndexSize is the size an index can't be larger than, which is far more than real indexes need
+const maxIndexSize = 10 << 20
+
+type Index struct {
+	Plugins map[string]Plugin `json:"plugins" validate:"required,dive"`
+}
+
+// Plugin is the entry of a plugin in an index
+type Plugin struct {
+	MetadataUrl string `json:"metadataUrl" validate:"required"`
+	Description string `json:"description"`
+}
+
+// Fetch loads the index at indexUrl, with the metadata urls resolved against it
+func Fetch(client *http.Client, indexUrl string) (Index, error) {
+	base, err := url.Parse(indexUrl)
+	if err != nil {
+		return Index{}, err
+	}
+	var body io.ReadCloser
+	switch base.Scheme {
+	case "http", "https":
+		resp, err := client.Get(indexUrl)
+		if err != nil {
+			return Index{}, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			_ = resp.Body.Close()
+			return Index{}, fmt.Errorf("%v: %v %v", indexUrl, resp.StatusCode, http.StatusText(resp.StatusCode))
+		}
+		body = resp.Body
+	case "file":
+		body, err = os.Open(shared.FileUrlPath(base))
+		if err != nil {
+			return Index{}, err
+		}
+	default:
+		return Index{}, fmt.Errorf("%w: %v", ErrUnsupportedUrl, indexUrl)
+	}
+	defer body.Close()
+	data, err := io.ReadAll(io.LimitReader(body, maxIndexSize+1))
+	if err != nil {
+		return Index{}, err
+	}
+	if len(data) > maxIndexSize {
+		return Index{}, fmt.Errorf("%w: %v", ErrIndexTooLarge, indexUrl)
+	}
+	var index Index
+	err = json.Unmarshal(data, &index)
+	if err != nil {
+		return Index{}, fmt.Errorf("%v: %w", indexUrl, err)
+	}
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	err = validate.Struct(index)
+	if err != nil {
+		return Index{}, fmt.Errorf("%v: %w", indexUrl, err)
+	}
+	for name, plugin := range index.Plugins {
+		metadataUrl, err := base.Parse(plugin.MetadataUrl)
+		if err != nil {
+			return Index{}, fmt.Errorf("%v: %w", indexUrl, err)
+		}
+		plugin.MetadataUrl = metadataUrl.String()
+		index.Plugins[name] = plugin
+	}
+	return index, nil
+}
+
+// Resolve returns the metadata url of the plugin with the given name from the first index that has it
+func Resolve(client *http.Client, indexUrls []string, pluginName string) (string, error) {
+	if len(indexUrls) == 0 {
+		return "", ErrNoIndexes
+	}
+	for _, indexUrl := range indexUrls {
+		index, err := Fetch(client, indexUrl)
+		if err != nil {
+			return "", err
+		}
+		if plugin, ok := index.Plugins[pluginName]; ok {
+			return plugin.MetadataUrl, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %v", ErrNotInIndex, pluginName)
+}
@@ -0,0 +1,85 @@
+package index
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MTVersionManager/mtvm/shared"
+)
+
+func TestResolveHttp(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/first/index.json":
+			_, _ = w.Write([]byte(`{"plugins": {"loremIpsum": {"metadataUrl": "loremIpsum.json"}}}`))
+		case "/second/index.json":
+			_, _ = w.Write([]byte(`{"plugins": {"loremIpsum": {"metadataUrl": "https://example.com/other.json"}, "dolorSitAmet": {"metadataUrl": "https://example.com/dolorSitAmet.json"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	indexUrls := []string{server.URL + "/first/index.json", server.URL + "/second/index.json"}
+	tests := map[string]struct {
+		pluginName string
+		want       string
+		wantErr    error
+	}{
+		"relative url":     {pluginName: "loremIpsum", want: server.URL + "/first/loremIpsum.json"},
+		"second index":     {pluginName: "dolorSitAmet", want: "https://example.com/dolorSitAmet.json"},
+		"not in any index": {pluginName: "consectetur", wantErr: ErrNotInIndex},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := Resolve(server.Client(), indexUrls, tt.pluginName)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("want error %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Fatalf("want %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestResolveFile(t *testing.T) {
+	indexPath := filepath.Join(t.TempDir(), "index.json")
+	err := os.WriteFile(indexPath, []byte(`{"plugins": {"loremIpsum": {"metadataUrl": "https://example.com/loremIpsum.json", "description": "Lorem ipsum"}}}`), 0o666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	indexUrl, err := shared.FileUrl(indexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Resolve(http.DefaultClient, []string{indexUrl}, "loremIpsum")
+	if err != nil {
+		t.Fatalf("want no error, got %v", err)
+	}
+	if got != "https://example.com/loremIpsum.json" {
+		t.Fatalf("want https://example.com/loremIpsum.json, got %v", got)
+	}
+}
+
+func TestResolveWithoutIndexes(t *testing.T) {
+	_, err := Resolve(http.DefaultClient, nil, "loremIpsum")
+	if !errors.Is(err, ErrNoIndexes) {
+		t.Fatalf("want ErrNoIndexes, got %v", err)
+	}
+}
+
+func TestFetchTooLarge(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"plugins": {}, "padding": "` + strings.Repeat("a", maxIndexSize) + `"}`))
+	}))
+	defer server.Close()
+	_, err := Fetch(server.Client(), server.URL)
+	if !errors.Is(err, ErrIndexTooLarge) {
+		t.Fatalf("want ErrIndexTooLarge, got %v", err)
+	}
+}
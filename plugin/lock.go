@@ -0,0 +1,78 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/MTVersionManager/mtvm/config"
+	"github.com/spf13/afero"
+)
+
+// entriesMutex serializes changes to plugins.json within this process.
+// File locks don't work on file systems other than the OS file system, and goroutines sharing a lock file descriptor wouldn't block each other.
+var entriesMutex sync.Mutex
+
+// lockEntries takes an exclusive lock on plugins.json, so that reading, changing and writing it can't interleave with another mtvm process doing the same.
+// The lock is advisory and held on plugins.json.lock, because plugins.json itself is replaced when it is written.
+// The returned function releases the lock.
+func lockEntries(fs afero.Fs) (func(), error) {
+	entriesMutex.Lock()
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		entriesMutex.Unlock()
+		return nil, err
+	}
+	err = fs.MkdirAll(configDir, 0o777)
+	if err != nil {
+		entriesMutex.Unlock()
+		return nil, err
+	}
+	file, err := fs.OpenFile(filepath.Join(configDir, "plugins.json.lock"), os.O_CREATE|os.O_RDWR, 0o666)
+	if err != nil {
+		entriesMutex.Unlock()
+		return nil, err
+	}
+	osFile, isOsFile := file.(*os.File)
+	if isOsFile {
+		err = lockFile(osFile)
+		if err != nil {
+			_ = file.Close()
+			entriesMutex.Unlock()
+			return nil, err
+		}
+	}
+	return func() {
+		if isOsFile {
+			_ = unlockFile(osFile)
+		}
+		_ = file.Close()
+		entriesMutex.Unlock()
+	}, nil
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it to path,
+// so path contains either the old or the new data even if mtvm is interrupted while writing
+func writeFileAtomic(fs afero.Fs, path string, data []byte) error {
+	file, err := afero.TempFile(fs, filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = fs.Chmod(file.Name(), 0o644)
+	}
+	if err == nil {
+		err = fs.Rename(file.Name(), path)
+	}
+	if err != nil {
+		_ = fs.Remove(file.Name())
+	}
+	return err
+}
@@ -0,0 +1,137 @@
+package plugin
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+func TestUpdateEntriesConcurrently(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("MTVM_CONFIG_DIR", configDir)
+	fs := afero.NewOsFs()
+	const writers = 20
+	const updates = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, writers*updates)
+	for i := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range updates {
+				errs <- UpdateEntries(Entry{
+					Name:        fmt.Sprintf("plugin%v", i),
+					Version:     fmt.Sprintf("0.0.%v", j),
+					MetadataUrl: "https://example.com",
+				}, fs)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("want no error, got %v", err)
+		}
+	}
+	entries, err := GetEntries(fs)
+	if err != nil {
+		t.Fatalf("want no error when getting entries, got %v", err)
+	}
+	if len(entries) != writers {
+		t.Fatalf("want %v entries, got %v entries containing %v", writers, len(entries), entries)
+	}
+	for _, entry := range entries {
+		if entry.Version != fmt.Sprintf("0.0.%v", updates-1) {
+			t.Fatalf("want every entry to have the last version written, got %v", entry)
+		}
+	}
+	leftovers, err := filepath.Glob(filepath.Join(configDir, "*.tmp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(leftovers) != 0 {
+		t.Fatalf("want no temporary files left, got %v", leftovers)
+	}
+}
+
+func TestUpdateAndRemoveEntriesConcurrently(t *testing.T) {
+	t.Setenv("MTVM_CONFIG_DIR", t.TempDir())
+	fs := afero.NewOsFs()
+	const plugins = 20
+	for i := range plugins {
+		err := UpdateEntries(Entry{Name: fmt.Sprintf("removed%v", i), Version: "0.0.0", MetadataUrl: "https://example.com"}, fs)
+		if err != nil {
+			t.Fatalf("want no error when adding entries, got %v", err)
+		}
+	}
+	var wg sync.WaitGroup
+	errs := make(chan error, plugins*2)
+	for i := range plugins {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			errs <- RemoveEntry(fmt.Sprintf("removed%v", i), fs)
+		}()
+		go func() {
+			defer wg.Done()
+			errs <- UpdateEntries(Entry{Name: fmt.Sprintf("added%v", i), Version: "0.0.0", MetadataUrl: "https://example.com"}, fs)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("want no error, got %v", err)
+		}
+	}
+	entries, err := GetEntries(fs)
+	if err != nil {
+		t.Fatalf("want no error when getting entries, got %v", err)
+	}
+	if len(entries) != plugins {
+		t.Fatalf("want %v entries, got %v entries containing %v", plugins, len(entries), entries)
+	}
+}
+
+func TestLockFileBlocksOtherFileDescriptors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plugins.json.lock")
+	first, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+	second, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Close()
+	if err = lockFile(first); err != nil {
+		t.Fatalf("want no error when locking, got %v", err)
+	}
+	locked := make(chan error)
+	go func() {
+		locked <- lockFile(second)
+	}()
+	select {
+	case <-locked:
+		t.Fatal("want the second lock to wait for the first one to be released")
+	case <-time.After(100 * time.Millisecond):
+	}
+	if err = unlockFile(first); err != nil {
+		t.Fatalf("want no error when unlocking, got %v", err)
+	}
+	select {
+	case err = <-locked:
+		if err != nil {
+			t.Fatalf("want no error when locking, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("want the second lock to be taken once the first one is released")
+	}
+}
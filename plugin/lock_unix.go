@@ -0,0 +1,23 @@
+//go:build unix
+
+package plugin
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// lockFile blocks until it has an exclusive lock on the file
+func lockFile(file *os.File) error {
+	for {
+		err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX)
+		if !errors.Is(err, syscall.EINTR) {
+			return err
+		}
+	}
+}
+
+func unlockFile(file *os.File) error {
+	return syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+}
@@ -0,0 +1,194 @@
+package manifest
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Extract extracts an archive of the given type into dir, leaving out the first stripComponents path elements of every entry
+func Extract(archive, archivePath, dir string, stripComponents int) error {
+	switch archive {
+	case ArchiveTarGz:
+		return extractTarGz(archivePath, dir, stripComponents)
+	case ArchiveZip:
+		return extractZip(archivePath, dir, stripComponents)
+	default:
+		return fmt.Errorf("unsupported archive type %v", archive)
+	}
+}
+
+// targetPath returns where an archive entry is extracted to, or an empty string if it is stripped away.
+// It returns an error if the entry would end up outside of dir, or would be written through a symlink already in dir.
+func targetPath(dir, name string, stripComponents int) (string, error) {
+	parts := strings.Split(strings.Trim(filepath.ToSlash(name), "/"), "/")
+	if len(parts) <= stripComponents {
+		return "", nil
+	}
+	target := filepath.Join(dir, filepath.Join(parts[stripComponents:]...))
+	if !isWithin(dir, target) {
+		return "", fmt.Errorf("archive entry %v is outside of the install directory", name)
+	}
+	err := checkNoSymlinks(dir, target)
+	if err != nil {
+		return "", fmt.Errorf("archive entry %v: %w", name, err)
+	}
+	return target, nil
+}
+
+// isWithin reports whether path is dir or inside of it, only looking at the paths
+func isWithin(dir, path string) bool {
+	dir = filepath.Clean(dir)
+	path = filepath.Clean(path)
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+// checkNoSymlinks returns an error if target or one of the directories between dir and target is a symlink,
+// because writing through it could end up outside of dir
+func checkNoSymlinks(dir, target string) error {
+	rel, err := filepath.Rel(dir, target)
+	if err != nil {
+		return err
+	}
+	current := filepath.Clean(dir)
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		if part == "." {
+			continue
+		}
+		current = filepath.Join(current, part)
+		info, err := os.Lstat(current)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("%v is a symlink", current)
+		}
+	}
+	return nil
+}
+
+// checkLinkname returns an error if a symlink at target pointing to linkname would point outside of dir
+func checkLinkname(dir, target, linkname string) error {
+	if filepath.IsAbs(linkname) || strings.HasPrefix(linkname, "/") {
+		return fmt.Errorf("symlink %v points to the absolute path %v", target, linkname)
+	}
+	if !isWithin(dir, filepath.Join(filepath.Dir(target), linkname)) {
+		return fmt.Errorf("symlink %v points outside of the install directory to %v", target, linkname)
+	}
+	return nil
+}
+
+func extractTarGz(archivePath, dir string, stripComponents int) error {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	gzipReader, err := gzip.NewReader(file)
+	if err != nil {
+		return err
+	}
+	defer gzipReader.Close()
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		target, err := targetPath(dir, header.Name, stripComponents)
+		if err != nil {
+			return err
+		}
+		if target == "" {
+			continue
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, 0o755)
+		case tar.TypeReg:
+			err = writeFile(target, tarReader, header.FileInfo().Mode().Perm())
+		case tar.TypeSymlink:
+			err = checkLinkname(dir, target, header.Linkname)
+			if err == nil {
+				err = os.MkdirAll(filepath.Dir(target), 0o755)
+			}
+			if err == nil {
+				err = os.Symlink(header.Linkname, target)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func extractZip(archivePath, dir string, stripComponents int) error {
+	zipReader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return err
+	}
+	defer zipReader.Close()
+	for _, zipFile := range zipReader.File {
+		target, err := targetPath(dir, zipFile.Name, stripComponents)
+		if err != nil {
+			return err
+		}
+		if target == "" {
+			continue
+		}
+		if zipFile.FileInfo().IsDir() {
+			err = os.MkdirAll(target, 0o755)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		reader, err := zipFile.Open()
+		if err != nil {
+			return err
+		}
+		err = writeFile(target, reader, zipFile.Mode().Perm())
+		reader.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyFile(source, target string, perm os.FileMode) error {
+	file, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return writeFile(target, file, perm)
+}
+
+func writeFile(target string, reader io.Reader, perm os.FileMode) error {
+	err := os.MkdirAll(filepath.Dir(target), 0o755)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(file, reader)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
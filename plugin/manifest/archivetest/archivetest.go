@@ -0,0 +1,58 @@
+// Package archivetest builds archives for tests of code that extracts plugins.
+package archivetest
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"testing"
+)
+
+// TarGz returns a .tar.gz archive with the files, which are mapped from their names to their content
+func TarGz(t testing.TB, files map[string]string) []byte {
+	t.Helper()
+	return write(t, func(tarWriter *tar.Writer) {
+		for name, content := range files {
+			err := tarWriter.WriteHeader(&tar.Header{
+				Name:     name,
+				Mode:     0o755,
+				Size:     int64(len(content)),
+				Typeflag: tar.TypeReg,
+			})
+			if err != nil {
+				t.Fatalf("want no error when writing tar header, got %v", err)
+			}
+			_, err = tarWriter.Write([]byte(content))
+			if err != nil {
+				t.Fatalf("want no error when writing tar content, got %v", err)
+			}
+		}
+	})
+}
+
+// TarGzHeaders returns a .tar.gz archive with the headers in order, regular files are empty
+func TarGzHeaders(t testing.TB, headers []tar.Header) []byte {
+	t.Helper()
+	return write(t, func(tarWriter *tar.Writer) {
+		for _, header := range headers {
+			if err := tarWriter.WriteHeader(&header); err != nil {
+				t.Fatalf("want no error when writing tar header, got %v", err)
+			}
+		}
+	})
+}
+
+// write returns the .tar.gz archive that writeEntries writes
+func write(t testing.TB, writeEntries func(*tar.Writer)) []byte {
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+	writeEntries(tarWriter)
+	if err := tarWriter.Close(); err != nil {
+		t.Fatalf("want no error when closing tar writer, got %v", err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		t.Fatalf("want no error when closing gzip writer, got %v", err)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,134 @@
+// Package manifest implements plugins that are described by a JSON manifest instead of compiled code.
+// They work for tools that are published as archives at predictable URLs, which most CLIs are.
+package manifest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Type is the value of the type field that marks a JSON document as a manifest
+const Type = "manifest"
+
+const (
+	ArchiveTarGz = "tar.gz"
+	ArchiveZip   = "zip"
+	// ArchiveNone means the downloaded file is the binary itself
+	ArchiveNone = "none"
+)
+
+const (
+	LatestFormatText = "text"
+	LatestFormatJson = "json"
+)
+
+var (
+	ErrNoVersionSource = errors.New("manifest needs either latest or versions to find the latest version")
+	// ErrInvalidBinaryPath is returned if a binary path is absolute or leaves the install directory with ..
+	ErrInvalidBinaryPath = errors.New("binary paths must be relative and inside of the install directory")
+)
+
+// Manifest describes how to download and install a tool
+type Manifest struct {
+	Type string `json:"type" validate:"required,eq=manifest"`
+	Name string `json:"name" validate:"required"`
+	// Version is the version of the manifest itself, not of the tool
+	Version string `json:"version" validate:"required,semver"`
+	// Url is the download url of the tool, where {{version}}, {{os}} and {{arch}} are replaced
+	Url string `json:"url" validate:"required"`
+	// OS maps values of runtime.GOOS to what {{os}} is replaced with, unmapped values are used as is
+	OS map[string]string `json:"os"`
+	// Arch maps values of runtime.GOARCH to what {{arch}} is replaced with, unmapped values are used as is
+	Arch            map[string]string `json:"arch"`
+	Archive         string            `json:"archive" validate:"required,oneof=tar.gz zip none"`
+	StripComponents int               `json:"stripComponents" validate:"min=0"`
+	// Binaries are the paths of the binaries inside the archive that get linked into the path directory
+	Binaries []string      `json:"binaries" validate:"required,min=1,dive,required"`
+	Latest   *LatestSource `json:"latest"`
+	// Versions is a list of versions, the highest one is used as the latest version if Latest is not set
+	Versions []string `json:"versions" validate:"dive,semver"`
+}
+
+// LatestSource is where the latest version of the tool is looked up
+type LatestSource struct {
+	Url    string `json:"url" validate:"required,http_url"`
+	Format string `json:"format" validate:"required,oneof=text json"`
+	// Key is the key of the version in the JSON object returned by Url, only used for LatestFormatJson
+	Key        string `json:"key" validate:"required_if=Format json"`
+	TrimPrefix string `json:"trimPrefix"`
+}
+
+// IsManifest reports whether a JSON document is a manifest rather than plugin metadata
+func IsManifest(rawData []byte) bool {
+	var document struct {
+		Type string `json:"type"`
+	}
+	err := json.Unmarshal(rawData, &document)
+	return err == nil && document.Type == Type
+}
+
+// Parse parses and validates a manifest
+func Parse(rawData []byte) (Manifest, error) {
+	var manifest Manifest
+	err := json.Unmarshal(rawData, &manifest)
+	if err != nil {
+		return manifest, err
+	}
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	err = validate.Struct(manifest)
+	if err != nil {
+		return manifest, err
+	}
+	if manifest.Latest == nil && len(manifest.Versions) == 0 {
+		return manifest, ErrNoVersionSource
+	}
+	for _, binary := range manifest.Binaries {
+		if !isRelativeInside(binary) {
+			return manifest, fmt.Errorf("%w: %v", ErrInvalidBinaryPath, binary)
+		}
+	}
+	return manifest, nil
+}
+
+// isRelativeInside reports whether path is relative and has no .. in it, with either kind of separator
+func isRelativeInside(path string) bool {
+	slashed := strings.ReplaceAll(path, "\\", "/")
+	if filepath.IsAbs(path) || strings.HasPrefix(slashed, "/") || filepath.VolumeName(path) != "" {
+		return false
+	}
+	return !slices.Contains(strings.Split(slashed, "/"), "..")
+}
+
+// Load reads a manifest file and returns a plugin for it
+func Load(path string) (*Plugin, error) {
+	rawData, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := Parse(rawData)
+	if err != nil {
+		return nil, err
+	}
+	return New(manifest), nil
+}
+
+// DownloadUrl returns the url the given version of the tool is downloaded from on this system
+func (m Manifest) DownloadUrl(version string) string {
+	osName, ok := m.OS[runtime.GOOS]
+	if !ok {
+		osName = runtime.GOOS
+	}
+	archName, ok := m.Arch[runtime.GOARCH]
+	if !ok {
+		archName = runtime.GOARCH
+	}
+	return strings.NewReplacer("{{version}}", version, "{{os}}", osName, "{{arch}}", archName).Replace(m.Url)
+}
@@ -0,0 +1,293 @@
+package manifest
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/MTVersionManager/mtvm/plugin/api"
+	"github.com/MTVersionManager/mtvm/plugin/manifest/archivetest"
+)
+
+func TestIsManifest(t *testing.T) {
+	tests := map[string]struct {
+		content string
+		want    bool
+	}{
+		"manifest": {content: `{"type": "manifest"}`, want: true},
+		"metadata": {content: `{"name": "loremIpsum", "version": "0.0.0"}`, want: false},
+		"invalid":  {content: `not json`, want: false},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := IsManifest([]byte(tt.content)); got != tt.want {
+				t.Fatalf("want %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseWithoutVersionSource(t *testing.T) {
+	_, err := Parse([]byte(`{
+	"type": "manifest",
+	"name": "loremIpsum",
+	"version": "0.0.0",
+	"url": "https://example.com/{{version}}.tar.gz",
+	"archive": "tar.gz",
+	"binaries": ["bin/loremIpsum"]
+}`))
+	if !errors.Is(err, ErrNoVersionSource) {
+		t.Fatalf("want ErrNoVersionSource, got %v", err)
+	}
+}
+
+func TestParseBinaryPaths(t *testing.T) {
+	tests := map[string]struct {
+		binary  string
+		wantErr error
+	}{
+		"relative":          {binary: "bin/loremIpsum"},
+		"dots in name":      {binary: "bin/lorem..ipsum"},
+		"absolute":          {binary: "/usr/bin/loremIpsum", wantErr: ErrInvalidBinaryPath},
+		"parent":            {binary: "../../../.bashrc", wantErr: ErrInvalidBinaryPath},
+		"parent inside":     {binary: "bin/../../loremIpsum", wantErr: ErrInvalidBinaryPath},
+		"windows separator": {binary: "bin\\..\\..\\loremIpsum", wantErr: ErrInvalidBinaryPath},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			rawData, err := json.Marshal(Manifest{
+				Type:     Type,
+				Name:     "loremIpsum",
+				Version:  "0.0.0",
+				Url:      "https://example.com/{{version}}/loremIpsum",
+				Archive:  ArchiveNone,
+				Binaries: []string{tt.binary},
+				Versions: []string{"1.0.0"},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = Parse(rawData)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("want error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestInstallUseTraversingBinary(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("#!/bin/sh\n"))
+	}))
+	defer server.Close()
+	// The manifest is not parsed, so the binary path isn't validated before installing
+	plugin := New(Manifest{
+		Name:     "tool",
+		Url:      server.URL + "/{{version}}/tool",
+		Archive:  ArchiveNone,
+		Binaries: []string{"../../outside"},
+	})
+	toolDir := t.TempDir()
+	installDir := filepath.Join(toolDir, "tool", "1.0.0")
+	progress := make(chan float64, 100)
+	if err := plugin.Download("1.0.0", progress); err != nil {
+		t.Fatalf("want no error when downloading, got %v", err)
+	}
+	if err := plugin.Install(installDir); err == nil {
+		t.Fatal("want error when installing a binary outside of the install directory, got nil")
+	}
+	if _, err := os.Lstat(filepath.Join(toolDir, "outside")); !os.IsNotExist(err) {
+		t.Fatalf("want nothing to be written outside of the install directory, got %v (stat)", err)
+	}
+	pathDir := t.TempDir()
+	if err := plugin.Use(installDir, pathDir); !errors.Is(err, ErrInvalidBinaryPath) {
+		t.Fatalf("want ErrInvalidBinaryPath when using, got %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(pathDir, "outside")); !os.IsNotExist(err) {
+		t.Fatalf("want no link to be created, got %v (stat)", err)
+	}
+}
+
+func TestDownloadUrl(t *testing.T) {
+	manifest := Manifest{
+		Url:  "https://example.com/{{version}}/tool-{{os}}-{{arch}}.tar.gz",
+		Arch: map[string]string{runtime.GOARCH: "mapped"},
+	}
+	want := "https://example.com/1.0.0/tool-" + runtime.GOOS + "-mapped.tar.gz"
+	if got := manifest.DownloadUrl("1.0.0"); got != want {
+		t.Fatalf("want %v, got %v", want, got)
+	}
+}
+
+func TestGetLatestVersionJson(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"tag_name": "v1.2.3"}`))
+	}))
+	defer server.Close()
+	plugin := New(Manifest{Latest: &LatestSource{
+		Url:        server.URL,
+		Format:     LatestFormatJson,
+		Key:        "tag_name",
+		TrimPrefix: "v",
+	}})
+	version, err := plugin.GetLatestVersion()
+	if err != nil {
+		t.Fatalf("want no error, got %v", err)
+	}
+	if version != "1.2.3" {
+		t.Fatalf("want version 1.2.3, got %v", version)
+	}
+}
+
+func TestGetLatestVersionFromVersions(t *testing.T) {
+	plugin := New(Manifest{Versions: []string{"1.2.3", "1.10.0", "1.9.9"}})
+	version, err := plugin.GetLatestVersion()
+	if err != nil {
+		t.Fatalf("want no error, got %v", err)
+	}
+	if version != "1.10.0" {
+		t.Fatalf("want version 1.10.0, got %v", version)
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	withVersions := New(Manifest{Versions: []string{"1.0.0"}})
+	if !api.Supports(withVersions, api.CapabilityVersionLister) {
+		t.Fatal("want manifest with versions to support listing versions")
+	}
+	withoutVersions := New(Manifest{Latest: &LatestSource{}})
+	if api.Supports(withoutVersions, api.CapabilityVersionLister) {
+		t.Fatal("want manifest without versions to not support listing versions")
+	}
+	if !api.Supports(withoutVersions, api.CapabilityVerifier) {
+		t.Fatal("want manifest to support verifying")
+	}
+}
+
+func TestInstallUseRemove(t *testing.T) {
+	archive := archivetest.TarGz(t, map[string]string{
+		"tool-1.0.0/bin/tool": "#!/bin/sh\n",
+		"tool-1.0.0/README":   "readme",
+	})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/1.0.0/tool.tar.gz" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(archive)
+	}))
+	defer server.Close()
+	plugin := New(Manifest{
+		Name:            "tool",
+		Url:             server.URL + "/{{version}}/tool.tar.gz",
+		Archive:         ArchiveTarGz,
+		StripComponents: 1,
+		Binaries:        []string{"bin/tool"},
+	})
+	toolDir := t.TempDir()
+	pathDir := t.TempDir()
+	installDir := filepath.Join(toolDir, "1.0.0")
+
+	progress := make(chan float64)
+	errChannel := make(chan error)
+	go func() {
+		errChannel <- plugin.Download("1.0.0", progress)
+	}()
+	for value := range progress {
+		if value == 1 {
+			break
+		}
+	}
+	if err := <-errChannel; err != nil {
+		t.Fatalf("want no error when downloading, got %v", err)
+	}
+	if err := plugin.Install(installDir); err != nil {
+		t.Fatalf("want no error when installing, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(installDir, "README")); err != nil {
+		t.Fatalf("want README to be extracted without its top level directory, got %v (stat)", err)
+	}
+	if err := plugin.Use(installDir, pathDir); err != nil {
+		t.Fatalf("want no error when using, got %v", err)
+	}
+	version, err := plugin.GetCurrentVersion(toolDir, pathDir)
+	if err != nil {
+		t.Fatalf("want no error when getting current version, got %v", err)
+	}
+	if version != "1.0.0" {
+		t.Fatalf("want current version 1.0.0, got '%v'", version)
+	}
+	if err := plugin.Remove(installDir, pathDir, true); err != nil {
+		t.Fatalf("want no error when removing, got %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(pathDir, "tool")); !os.IsNotExist(err) {
+		t.Fatalf("want link to be removed, got %v (stat)", err)
+	}
+	if _, err := os.Stat(installDir); !os.IsNotExist(err) {
+		t.Fatalf("want install directory to be removed, got %v (stat)", err)
+	}
+}
+
+func TestTargetPathOutsideDir(t *testing.T) {
+	_, err := targetPath(t.TempDir(), "../../etc/passwd", 0)
+	if err == nil {
+		t.Fatal("want error, got nil")
+	}
+}
+
+func TestExtractTarGzSymlinks(t *testing.T) {
+	tests := map[string]struct {
+		entries []tar.Header
+		// existingLink is a symlink to the outside directory created in the install directory before extracting
+		existingLink string
+		wantErr      bool
+	}{
+		"link inside":       {entries: []tar.Header{{Name: "lib/tool.so", Typeflag: tar.TypeSymlink, Linkname: "tool.so.1"}}},
+		"absolute link":     {entries: []tar.Header{{Name: "evil", Typeflag: tar.TypeSymlink, Linkname: "/"}}, wantErr: true},
+		"link outside":      {entries: []tar.Header{{Name: "evil", Typeflag: tar.TypeSymlink, Linkname: "../outside"}}, wantErr: true},
+		"nested link above": {entries: []tar.Header{{Name: "lib/evil", Typeflag: tar.TypeSymlink, Linkname: "../.."}}, wantErr: true},
+		"link then file through it": {entries: []tar.Header{
+			{Name: "evil", Typeflag: tar.TypeSymlink, Linkname: "../outside"},
+			{Name: "evil/.bashrc", Typeflag: tar.TypeReg, Mode: 0o644},
+		}, wantErr: true},
+		"file through existing link": {entries: []tar.Header{{Name: "evil/.bashrc", Typeflag: tar.TypeReg, Mode: 0o644}}, existingLink: "evil", wantErr: true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			root := t.TempDir()
+			dir := filepath.Join(root, "install")
+			outside := filepath.Join(root, "outside")
+			for _, path := range []string{dir, outside} {
+				if err := os.Mkdir(path, 0o755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.existingLink != "" {
+				if err := os.Symlink(outside, filepath.Join(dir, tt.existingLink)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			archivePath := filepath.Join(root, "archive.tar.gz")
+			if err := os.WriteFile(archivePath, archivetest.TarGzHeaders(t, tt.entries), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			err := Extract(ArchiveTarGz, archivePath, dir, 0)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("want error %v, got %v", tt.wantErr, err)
+			}
+			written, err := os.ReadDir(outside)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(written) > 0 {
+				t.Fatalf("want nothing to be written outside of the install directory, found %v", written[0].Name())
+			}
+		})
+	}
+}
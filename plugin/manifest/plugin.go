@@ -0,0 +1,232 @@
+package manifest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/MTVersionManager/mtvm/plugin/api"
+	"github.com/Masterminds/semver/v3"
+)
+
+var ErrNotDownloaded = errors.New("install was called before download")
+
+// Plugin implements mtvmplugin.Plugin for a manifest
+type Plugin struct {
+	Manifest Manifest
+	// downloadedFile is the temporary file Download wrote the archive to
+	downloadedFile string
+}
+
+func New(manifest Manifest) *Plugin {
+	return &Plugin{Manifest: manifest}
+}
+
+// APIVersion always returns api.Version, as manifest plugins are implemented by mtvm itself
+func (p *Plugin) APIVersion() int {
+	return api.Version
+}
+
+// Supports reports whether the manifest has what is needed for a capability
+func (p *Plugin) Supports(capability string) bool {
+	switch capability {
+	case api.CapabilityVersionLister:
+		return len(p.Manifest.Versions) > 0
+	case api.CapabilityVerifier:
+		return true
+	default:
+		return false
+	}
+}
+
+// ListVersions returns the versions listed in the manifest
+func (p *Plugin) ListVersions() ([]string, error) {
+	if len(p.Manifest.Versions) == 0 {
+		return nil, ErrNoVersionSource
+	}
+	return p.Manifest.Versions, nil
+}
+
+// Verify checks that every binary of the manifest exists in installDir
+func (p *Plugin) Verify(installDir string) error {
+	for _, binary := range p.Manifest.Binaries {
+		_, err := os.Stat(filepath.Join(installDir, binary))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Plugin) GetLatestVersion() (string, error) {
+	if p.Manifest.Latest == nil {
+		var latest *semver.Version
+		for _, v := range p.Manifest.Versions {
+			version, err := semver.NewVersion(v)
+			if err != nil {
+				return "", err
+			}
+			if latest == nil || version.GreaterThan(latest) {
+				latest = version
+			}
+		}
+		if latest == nil {
+			return "", ErrNoVersionSource
+		}
+		return latest.Original(), nil
+	}
+	resp, err := http.Get(p.Manifest.Latest.Url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%v %v", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+	var version string
+	if p.Manifest.Latest.Format == LatestFormatJson {
+		var document map[string]any
+		err = json.NewDecoder(resp.Body).Decode(&document)
+		if err != nil {
+			return "", err
+		}
+		var ok bool
+		version, ok = document[p.Manifest.Latest.Key].(string)
+		if !ok {
+			return "", fmt.Errorf("latest version response has no string %v", p.Manifest.Latest.Key)
+		}
+	} else {
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return "", err
+		}
+		version = string(data)
+	}
+	return strings.TrimPrefix(strings.TrimSpace(version), p.Manifest.Latest.TrimPrefix), nil
+}
+
+func (p *Plugin) Download(version string, progress chan float64) error {
+	resp, err := http.Get(p.Manifest.DownloadUrl(version))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%v %v", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+	file, err := os.CreateTemp("", "mtvm-"+p.Manifest.Name+"-*")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(file, io.TeeReader(resp.Body, &ProgressWriter{
+		TotalSize: resp.ContentLength,
+		Progress:  progress,
+	}))
+	if err != nil {
+		_ = os.Remove(file.Name())
+		return err
+	}
+	p.downloadedFile = file.Name()
+	if progress != nil {
+		progress <- 1
+	}
+	return nil
+}
+
+func (p *Plugin) Install(installDir string) error {
+	if p.downloadedFile == "" {
+		return ErrNotDownloaded
+	}
+	defer func() {
+		_ = os.Remove(p.downloadedFile)
+		p.downloadedFile = ""
+	}()
+	err := os.MkdirAll(installDir, 0o755)
+	if err != nil {
+		return err
+	}
+	if p.Manifest.Archive == ArchiveNone {
+		var target string
+		target, err = targetPath(installDir, p.Manifest.Binaries[0], 0)
+		if err == nil {
+			err = copyFile(p.downloadedFile, target, 0o755)
+		}
+	} else {
+		err = Extract(p.Manifest.Archive, p.downloadedFile, installDir, p.Manifest.StripComponents)
+	}
+	if err != nil {
+		_ = os.RemoveAll(installDir)
+	}
+	return err
+}
+
+func (p *Plugin) Use(installDir string, pathDir string) error {
+	for _, binary := range p.Manifest.Binaries {
+		link := filepath.Join(pathDir, filepath.Base(binary))
+		err := os.Remove(link)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		target := filepath.Join(installDir, binary)
+		if !isWithin(installDir, target) {
+			return fmt.Errorf("%w: %v", ErrInvalidBinaryPath, binary)
+		}
+		err = os.Symlink(target, link)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Plugin) Remove(installDir string, pathDir string, inUse bool) error {
+	if inUse {
+		for _, binary := range p.Manifest.Binaries {
+			err := os.Remove(filepath.Join(pathDir, filepath.Base(binary)))
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return os.RemoveAll(installDir)
+}
+
+// GetCurrentVersion finds the version in use by following the link to the first binary.
+// Returns an empty string if no version is in use.
+func (p *Plugin) GetCurrentVersion(installDir string, pathDir string) (string, error) {
+	target, err := os.Readlink(filepath.Join(pathDir, filepath.Base(p.Manifest.Binaries[0])))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	relative, err := filepath.Rel(installDir, target)
+	if err != nil || relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+		return "", nil
+	}
+	return strings.SplitN(relative, string(filepath.Separator), 2)[0], nil
+}
+
+// ProgressWriter sends how much of TotalSize was written to Progress, so it can count what a plugin downloads.
+// It never sends 1, because 1 makes the host start installing, so plugins send it themselves once the download is ready.
+// Nothing is sent if TotalSize is unknown or Progress is nil.
+type ProgressWriter struct {
+	TotalSize      int64
+	Progress       chan float64
+	downloadedSize int64
+}
+
+func (pw *ProgressWriter) Write(p []byte) (int, error) {
+	pw.downloadedSize += int64(len(p))
+	if pw.Progress != nil && pw.TotalSize > 0 && pw.downloadedSize < pw.TotalSize {
+		pw.Progress <- float64(pw.downloadedSize) / float64(pw.TotalSize)
+	}
+	return len(p), nil
+}
@@ -0,0 +1,196 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// CheckMtvmVersion returns an error wrapping ErrIncompatibleMtvm if the plugin doesn't work with the given version of mtvm
+func (m Metadata) CheckMtvmVersion(mtvmVersion string) error {
+	current, err := semver.NewVersion(mtvmVersion)
+	if err != nil {
+		return err
+	}
+	if m.MinMtvmVersion != "" {
+		minVersion, err := semver.NewVersion(m.MinMtvmVersion)
+		if err != nil {
+			return err
+		}
+		if current.LessThan(minVersion) {
+			return fmt.Errorf("%w: the %v plugin needs mtvm %v or newer, but this is mtvm %v", ErrIncompatibleMtvm, m.Name, minVersion, current)
+		}
+	}
+	if m.MaxMtvmVersion != "" {
+		maxVersion, err := semver.NewVersion(m.MaxMtvmVersion)
+		if err != nil {
+			return err
+		}
+		if current.GreaterThan(maxVersion) {
+			return fmt.Errorf("%w: the %v plugin only works with mtvm %v or older, but this is mtvm %v", ErrIncompatibleMtvm, m.Name, maxVersion, current)
+		}
+	}
+	return nil
+}
+
+// ResolveUrls returns the metadata with the download urls and mirrors resolved against the url the metadata was loaded from,
+// so downloads can be given as paths relative to the metadata.
+// Downloads from local files are only allowed if the metadata is a local file too.
+func (m Metadata) ResolveUrls(metadataUrl string) (Metadata, error) {
+	base, err := url.Parse(metadataUrl)
+	if err != nil {
+		return m, err
+	}
+	downloads, err := resolveDownloadUrls(base, m.Downloads)
+	if err != nil {
+		return m, err
+	}
+	releases := make([]Release, len(m.Releases))
+	for i, release := range m.Releases {
+		release.Downloads, err = resolveDownloadUrls(base, release.Downloads)
+		if err != nil {
+			return m, err
+		}
+		releases[i] = release
+	}
+	mirrors, err := resolveMirrors(base, m.Mirrors)
+	if err != nil {
+		return m, err
+	}
+	m.Downloads = downloads
+	m.Mirrors = mirrors
+	if m.Releases != nil {
+		m.Releases = releases
+	}
+	return m, nil
+}
+
+func resolveDownloadUrls(base *url.URL, downloads []Download) ([]Download, error) {
+	resolvedDownloads := make([]Download, len(downloads))
+	for i, download := range downloads {
+		var err error
+		download.Url, err = resolveUrl(base, download.Url)
+		if err != nil {
+			return nil, err
+		}
+		download.Mirrors, err = resolveMirrors(base, download.Mirrors)
+		if err != nil {
+			return nil, err
+		}
+		resolvedDownloads[i] = download
+	}
+	return resolvedDownloads, nil
+}
+
+func resolveMirrors(base *url.URL, mirrors []string) ([]string, error) {
+	if mirrors == nil {
+		return nil, nil
+	}
+	resolvedMirrors := make([]string, len(mirrors))
+	for i, mirror := range mirrors {
+		var err error
+		resolvedMirrors[i], err = resolveUrl(base, mirror)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return resolvedMirrors, nil
+}
+
+// resolveUrl resolves rawUrl against base, only allowing file urls if base is one
+func resolveUrl(base *url.URL, rawUrl string) (string, error) {
+	resolved, err := base.Parse(rawUrl)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case resolved.Scheme == "http" || resolved.Scheme == "https":
+	case resolved.Scheme == "file" && base.Scheme == "file":
+	default:
+		return "", fmt.Errorf("%w: %v", ErrInvalidDownloadUrl, rawUrl)
+	}
+	return resolved.String(), nil
+}
+
+// AllReleases returns the release described by Version and Downloads together with the ones in Releases,
+// leaving out releases listed more than once
+func (m Metadata) AllReleases() []Release {
+	var releases []Release
+	if m.Version != "" {
+		releases = append(releases, Release{
+			Version:        m.Version,
+			Downloads:      m.Downloads,
+			MinMtvmVersion: m.MinMtvmVersion,
+			MaxMtvmVersion: m.MaxMtvmVersion,
+		})
+	}
+	for _, release := range m.Releases {
+		if !slices.ContainsFunc(releases, func(r Release) bool { return r.Version == release.Version }) {
+			releases = append(releases, release)
+		}
+	}
+	return releases
+}
+
+// Select returns the metadata with Version and Downloads set to the newest release that matches the constraint and works with the given version of mtvm.
+// The constraint is a version or semver constraint like ^1.2, an empty constraint matches every release.
+// It returns an error wrapping ErrIncompatibleMtvm if matching releases exist but none of them works with this version of mtvm,
+// and an error wrapping ErrNoMatchingRelease if no release matches.
+func (m Metadata) Select(constraint string, mtvmVersion string) (Metadata, error) {
+	var constraints *semver.Constraints
+	if constraint != "" {
+		var err error
+		constraints, err = semver.NewConstraint(constraint)
+		if err != nil {
+			return m, err
+		}
+	}
+	type candidate struct {
+		release Release
+		version *semver.Version
+	}
+	var candidates []candidate
+	for _, release := range m.AllReleases() {
+		version, err := semver.NewVersion(release.Version)
+		if err != nil {
+			return m, err
+		}
+		if constraints == nil || constraints.Check(version) {
+			candidates = append(candidates, candidate{release, version})
+		}
+	}
+	slices.SortFunc(candidates, func(a, b candidate) int {
+		return b.version.Compare(a.version)
+	})
+	var incompatibleErr error
+	for _, candidate := range candidates {
+		selected := m
+		selected.Version = candidate.release.Version
+		selected.Downloads = candidate.release.Downloads
+		if candidate.release.MinMtvmVersion != "" {
+			selected.MinMtvmVersion = candidate.release.MinMtvmVersion
+		}
+		if candidate.release.MaxMtvmVersion != "" {
+			selected.MaxMtvmVersion = candidate.release.MaxMtvmVersion
+		}
+		err := selected.CheckMtvmVersion(mtvmVersion)
+		if errors.Is(err, ErrIncompatibleMtvm) {
+			// An older release might still work with this version of mtvm, but the error of the newest is the most helpful one
+			if incompatibleErr == nil {
+				incompatibleErr = err
+			}
+			continue
+		}
+		if err != nil {
+			return m, err
+		}
+		return selected, nil
+	}
+	if incompatibleErr != nil {
+		return m, incompatibleErr
+	}
+	return m, fmt.Errorf("%w: the %v plugin has no release matching %v", ErrNoMatchingRelease, m.Name, constraint)
+}
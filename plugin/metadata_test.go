@@ -0,0 +1,148 @@
+package plugin
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestCheckMtvmVersion(t *testing.T) {
+	tests := map[string]struct {
+		metadata   Metadata
+		wantsError bool
+	}{
+		"no bounds": {
+			metadata: Metadata{Name: "loremIpsum"},
+		},
+		"within bounds": {
+			metadata: Metadata{Name: "loremIpsum", MinMtvmVersion: "1.0.0", MaxMtvmVersion: "2.0.0"},
+		},
+		"too old": {
+			metadata:   Metadata{Name: "loremIpsum", MinMtvmVersion: "1.5.0"},
+			wantsError: true,
+		},
+		"too new": {
+			metadata:   Metadata{Name: "loremIpsum", MaxMtvmVersion: "1.0.0"},
+			wantsError: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := tt.metadata.CheckMtvmVersion("1.2.0")
+			if tt.wantsError && !errors.Is(err, ErrIncompatibleMtvm) {
+				t.Fatalf("want error containing ErrIncompatibleMtvm, got %v", err)
+			}
+			if !tt.wantsError && err != nil {
+				t.Fatalf("want no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestResolveUrls(t *testing.T) {
+	tests := map[string]struct {
+		metadataUrl string
+		downloadUrl string
+		want        string
+		wantErr     error
+	}{
+		"absolute":                {metadataUrl: "https://example.com/plugins/loremIpsum.json", downloadUrl: "https://example.org/loremIpsum.so", want: "https://example.org/loremIpsum.so"},
+		"relative to http":        {metadataUrl: "https://example.com/plugins/loremIpsum.json", downloadUrl: "linux/loremIpsum.so", want: "https://example.com/plugins/linux/loremIpsum.so"},
+		"relative to file":        {metadataUrl: "file:///srv/plugins/loremIpsum.json", downloadUrl: "../loremIpsum.so", want: "file:///srv/loremIpsum.so"},
+		"file from http metadata": {metadataUrl: "https://example.com/loremIpsum.json", downloadUrl: "file:///etc/passwd", wantErr: ErrInvalidDownloadUrl},
+		"unsupported scheme":      {metadataUrl: "https://example.com/loremIpsum.json", downloadUrl: "ftp://example.com/loremIpsum.so", wantErr: ErrInvalidDownloadUrl},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			metadata := Metadata{Downloads: []Download{{Url: tt.downloadUrl}}}
+			resolved, err := metadata.ResolveUrls(tt.metadataUrl)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("want error %v, got %v", tt.wantErr, err)
+			}
+			if err == nil && resolved.Downloads[0].Url != tt.want {
+				t.Fatalf("want %v, got %v", tt.want, resolved.Downloads[0].Url)
+			}
+			if metadata.Downloads[0].Url != tt.downloadUrl {
+				t.Fatal("want original metadata to be unchanged")
+			}
+		})
+	}
+}
+
+func TestSelect(t *testing.T) {
+	metadata := Metadata{
+		Name:      "loremIpsum",
+		Version:   "2.1.0",
+		Downloads: []Download{{Url: "https://example.com/2.1.0"}},
+		Releases: []Release{
+			{Version: "2.1.0", Downloads: []Download{{Url: "https://example.com/duplicate"}}},
+			{Version: "1.2.0", Downloads: []Download{{Url: "https://example.com/1.2.0"}}},
+			{Version: "1.10.0", Downloads: []Download{{Url: "https://example.com/1.10.0"}}},
+			{Version: "3.0.0", Downloads: []Download{{Url: "https://example.com/3.0.0"}}, MinMtvmVersion: "9.0.0"},
+		},
+	}
+	tests := map[string]struct {
+		constraint  string
+		wantVersion string
+		wantErr     error
+	}{
+		"no constraint":              {constraint: "", wantVersion: "2.1.0"},
+		"exact version":              {constraint: "1.2.0", wantVersion: "1.2.0"},
+		"caret":                      {constraint: "^1.2", wantVersion: "1.10.0"},
+		"only incompatible releases": {constraint: ">=3", wantErr: ErrIncompatibleMtvm},
+		"no matching release":        {constraint: "~4.0", wantErr: ErrNoMatchingRelease},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			selected, err := metadata.Select(tt.constraint, "1.0.0")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("want error %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if selected.Version != tt.wantVersion {
+				t.Fatalf("want version %v, got %v", tt.wantVersion, selected.Version)
+			}
+			wantUrl := "https://example.com/" + tt.wantVersion
+			if len(selected.Downloads) != 1 || selected.Downloads[0].Url != wantUrl {
+				t.Fatalf("want the downloads of version %v, got %v", tt.wantVersion, selected.Downloads)
+			}
+		})
+	}
+}
+
+func TestResolveUrlsOfReleases(t *testing.T) {
+	metadata := Metadata{Releases: []Release{{Version: "1.0.0", Downloads: []Download{{Url: "1.0.0/loremIpsum.so"}}}}}
+	resolved, err := metadata.ResolveUrls("https://example.com/plugins/loremIpsum.json")
+	if err != nil {
+		t.Fatalf("want no error, got %v", err)
+	}
+	want := "https://example.com/plugins/1.0.0/loremIpsum.so"
+	if got := resolved.Releases[0].Downloads[0].Url; got != want {
+		t.Fatalf("want %v, got %v", want, got)
+	}
+}
+
+func TestResolveUrlsOfMirrors(t *testing.T) {
+	metadata := Metadata{
+		Downloads: []Download{{Url: "loremIpsum.so", Mirrors: []string{"https://mirror.example.org/loremIpsum.so", "mirror/loremIpsum.so"}}},
+		Mirrors:   []string{"https://mirror.example.org/loremIpsum.json"},
+	}
+	resolved, err := metadata.ResolveUrls("https://example.com/plugins/loremIpsum.json")
+	if err != nil {
+		t.Fatalf("want no error, got %v", err)
+	}
+	want := []string{"https://mirror.example.org/loremIpsum.so", "https://example.com/plugins/mirror/loremIpsum.so"}
+	if !slices.Equal(resolved.Downloads[0].Mirrors, want) {
+		t.Fatalf("want download mirrors %v, got %v", want, resolved.Downloads[0].Mirrors)
+	}
+	if !slices.Equal(resolved.Mirrors, metadata.Mirrors) {
+		t.Fatalf("want metadata mirrors %v, got %v", metadata.Mirrors, resolved.Mirrors)
+	}
+	metadata.Downloads[0].Mirrors = []string{"file:///loremIpsum.so"}
+	_, err = metadata.ResolveUrls("https://example.com/plugins/loremIpsum.json")
+	if !errors.Is(err, ErrInvalidDownloadUrl) {
+		t.Fatalf("want ErrInvalidDownloadUrl for a file mirror of remote metadata, got %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+package plugin
+
+import (
+	"net/url"
+	"slices"
+)
+
+// PreferMirror returns the urls with the first one on the same host as preferred moved to the front,
+// so the mirror that worked last time is tried first. The other urls keep their order.
+// The urls are returned unchanged if preferred is empty or none of them is on its host.
+func PreferMirror(urls []string, preferred string) []string {
+	if preferred == "" {
+		return urls
+	}
+	preferredHost, ok := mirrorHost(preferred)
+	if !ok {
+		return urls
+	}
+	i := slices.IndexFunc(urls, func(rawUrl string) bool {
+		host, ok := mirrorHost(rawUrl)
+		return ok && host == preferredHost
+	})
+	if i <= 0 {
+		return urls
+	}
+	ordered := make([]string, 0, len(urls))
+	ordered = append(ordered, urls[i])
+	ordered = append(ordered, urls[:i]...)
+	return append(ordered, urls[i+1:]...)
+}
+
+// mirrorHost returns the scheme and host of rawUrl, which tell mirrors apart.
+// File urls have no host, so the whole url is returned for them.
+func mirrorHost(rawUrl string) (string, bool) {
+	parsedUrl, err := url.Parse(rawUrl)
+	if err != nil {
+		return "", false
+	}
+	if parsedUrl.Scheme == "file" {
+		return rawUrl, true
+	}
+	return parsedUrl.Scheme + "://" + parsedUrl.Host, true
+}
+
+// MetadataUrls returns the urls the metadata of the entry can be loaded from, the one that worked last time first
+func (e Entry) MetadataUrls() []string {
+	urls := append([]string{e.MetadataUrl}, e.MetadataMirrors...)
+	i := slices.Index(urls, e.MetadataMirror)
+	if i <= 0 {
+		return urls
+	}
+	return append([]string{e.MetadataMirror}, slices.Delete(urls, i, i+1)...)
+}
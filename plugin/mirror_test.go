@@ -0,0 +1,46 @@
+package plugin
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPreferMirror(t *testing.T) {
+	urls := []string{"https://example.com/1.1.0/loremIpsum.so", "https://mirror.example.org/1.1.0/loremIpsum.so", "https://other.example.org/1.1.0/loremIpsum.so"}
+	tests := map[string]struct {
+		preferred string
+		want      []string
+	}{
+		"no preference":       {preferred: "", want: urls},
+		"url itself":          {preferred: "https://example.com/1.0.0/loremIpsum.so", want: urls},
+		"mirror on same host": {preferred: "https://other.example.org/1.0.0/loremIpsum.so", want: []string{urls[2], urls[0], urls[1]}},
+		"unknown host":        {preferred: "https://gone.example.org/1.0.0/loremIpsum.so", want: urls},
+		"same host, http":     {preferred: "http://mirror.example.org/1.0.0/loremIpsum.so", want: urls},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := PreferMirror(urls, tt.preferred); !slices.Equal(got, tt.want) {
+				t.Fatalf("want %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestMetadataUrls(t *testing.T) {
+	entry := Entry{
+		MetadataUrl:     "https://example.com/loremIpsum.json",
+		MetadataMirrors: []string{"https://mirror.example.org/loremIpsum.json", "https://other.example.org/loremIpsum.json"},
+	}
+	want := []string{entry.MetadataUrl, entry.MetadataMirrors[0], entry.MetadataMirrors[1]}
+	if got := entry.MetadataUrls(); !slices.Equal(got, want) {
+		t.Fatalf("want %v, got %v", want, got)
+	}
+	entry.MetadataMirror = entry.MetadataMirrors[1]
+	want = []string{entry.MetadataMirrors[1], entry.MetadataUrl, entry.MetadataMirrors[0]}
+	if got := entry.MetadataUrls(); !slices.Equal(got, want) {
+		t.Fatalf("want the last working mirror first, %v, got %v", want, got)
+	}
+	if len(entry.MetadataMirrors) != 2 || entry.MetadataMirrors[1] != "https://other.example.org/loremIpsum.json" {
+		t.Fatalf("want the mirrors of the entry to be left alone, got %v", entry.MetadataMirrors)
+	}
+}
@@ -0,0 +1,210 @@
+package plugin
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"golang.org/x/sys/cpu"
+)
+
+// Values of Download.Libc
+const (
+	LibcGlibc = "glibc"
+	LibcMusl  = "musl"
+)
+
+// Host describes the system downloads are chosen for
+type Host struct {
+	OS   string
+	Arch string
+	// Libc is the C library of a linux system, empty if it is unknown or the system isn't linux
+	Libc string
+	// AMD64Level is the GOAMD64 level the CPU supports, like 3 for v3, and 0 on other architectures
+	AMD64Level int
+}
+
+func (h Host) String() string {
+	var details []string
+	if h.Libc != "" {
+		details = append(details, h.Libc)
+	}
+	if h.AMD64Level != 0 {
+		details = append(details, fmt.Sprintf("GOAMD64 v%v", h.AMD64Level))
+	}
+	if len(details) == 0 {
+		return h.OS + "/" + h.Arch
+	}
+	return fmt.Sprintf("%v/%v (%v)", h.OS, h.Arch, strings.Join(details, ", "))
+}
+
+// DetectHost returns the Host mtvm is running on
+func DetectHost() Host {
+	host := Host{OS: runtime.GOOS, Arch: runtime.GOARCH}
+	if host.OS == "linux" {
+		host.Libc = detectLibc()
+	}
+	if host.Arch == "amd64" {
+		host.AMD64Level = detectAMD64Level()
+	}
+	return host
+}
+
+// detectLibc looks for the dynamic linker of musl and glibc, returning an empty string if it finds neither
+func detectLibc() string {
+	if matches, _ := filepath.Glob("/lib/ld-musl-*.so.1"); len(matches) > 0 {
+		return LibcMusl
+	}
+	for _, pattern := range []string{"/lib*/ld-linux*.so.*", "/lib/*/ld-linux*.so.*"} {
+		if matches, _ := filepath.Glob(pattern); len(matches) > 0 {
+			return LibcGlibc
+		}
+	}
+	return ""
+}
+
+// detectAMD64Level returns the highest GOAMD64 level the CPU supports.
+// The cpu package doesn't report every feature of the levels, so the missing ones are assumed to come with the ones checked.
+func detectAMD64Level() int {
+	x86 := cpu.X86
+	if !(x86.HasCX16 && x86.HasPOPCNT && x86.HasSSE3 && x86.HasSSSE3 && x86.HasSSE41 && x86.HasSSE42) {
+		return 1
+	}
+	if !(x86.HasAVX && x86.HasAVX2 && x86.HasBMI1 && x86.HasBMI2 && x86.HasFMA && x86.HasOSXSAVE) {
+		return 2
+	}
+	if !(x86.HasAVX512F && x86.HasAVX512BW && x86.HasAVX512CD && x86.HasAVX512DQ && x86.HasAVX512VL) {
+		return 3
+	}
+	return 4
+}
+
+// amd64Level returns the number of a GOAMD64 level like v3, and 1 for an empty level because v1 is what every amd64 CPU supports
+func amd64Level(level string) int {
+	switch level {
+	case "v2":
+		return 2
+	case "v3":
+		return 3
+	case "v4":
+		return 4
+	default:
+		return 1
+	}
+}
+
+func (d Download) String() string {
+	var details []string
+	if d.Libc != "" {
+		details = append(details, d.Libc)
+	}
+	if d.GOAMD64 != "" {
+		details = append(details, "GOAMD64 "+d.GOAMD64)
+	}
+	if len(details) == 0 {
+		return d.OS + "/" + d.Arch
+	}
+	return fmt.Sprintf("%v/%v (%v)", d.OS, d.Arch, strings.Join(details, ", "))
+}
+
+// fits returns an empty string if the download works on the host, and why it doesn't otherwise
+func (d Download) fits(host Host) string {
+	isAny := d.OS == AnyPlatform && d.Arch == AnyPlatform
+	if !isAny && (d.OS != host.OS || d.Arch != host.Arch) {
+		return fmt.Sprintf("built for %v/%v", d.OS, d.Arch)
+	}
+	if d.Libc != "" && host.Libc != "" && d.Libc != host.Libc {
+		return fmt.Sprintf("needs %v, this system uses %v", d.Libc, host.Libc)
+	}
+	if d.GOAMD64 != "" && host.Arch == "amd64" && amd64Level(d.GOAMD64) > host.AMD64Level {
+		return fmt.Sprintf("needs GOAMD64 %v, this CPU supports up to v%v", d.GOAMD64, host.AMD64Level)
+	}
+	return ""
+}
+
+// rank returns how well a download that fits the host suits it, higher is better.
+// Downloads for the exact platform beat ones for any platform, downloads for the libc of the host beat ones that don't say,
+// and downloads for a higher GOAMD64 level beat ones for a lower one.
+func (d Download) rank(host Host) int {
+	rank := 0
+	if d.OS != AnyPlatform {
+		rank += 100
+	}
+	if d.Libc != "" && d.Libc == host.Libc {
+		rank += 10
+	}
+	if d.GOAMD64 != "" {
+		rank += amd64Level(d.GOAMD64)
+	}
+	return rank
+}
+
+// RejectedDownload is a download SelectDownload didn't choose and why
+type RejectedDownload struct {
+	Download Download
+	Reason   string
+}
+
+// DownloadChoice is the result of SelectDownload
+type DownloadChoice struct {
+	Host     Host
+	Download Download
+	// Found is false if no download works on the host
+	Found    bool
+	Rejected []RejectedDownload
+}
+
+// HasAlternatives reports whether a download for the platform of the host wasn't chosen, which makes the choice worth explaining.
+// Downloads for other platforms never work on the host, so they don't count.
+func (c DownloadChoice) HasAlternatives() bool {
+	return slices.ContainsFunc(c.Rejected, func(rejected RejectedDownload) bool {
+		download := rejected.Download
+		return (download.OS == AnyPlatform && download.Arch == AnyPlatform) || (download.OS == c.Host.OS && download.Arch == c.Host.Arch)
+	})
+}
+
+// String explains which download was chosen and why the others weren't
+func (c DownloadChoice) String() string {
+	var explanation strings.Builder
+	if c.Found {
+		explanation.WriteString(fmt.Sprintf("Chose the %v download for %v", c.Download, c.Host))
+	} else {
+		explanation.WriteString(fmt.Sprintf("None of the downloads work on %v", c.Host))
+	}
+	for _, rejected := range c.Rejected {
+		explanation.WriteString(fmt.Sprintf("\n  %v: %v", rejected.Download, rejected.Reason))
+	}
+	return explanation.String()
+}
+
+// SelectDownload chooses the download that suits the host best, see Download.rank.
+// If downloads are ranked the same, the first one is chosen.
+func SelectDownload(downloads []Download, host Host) DownloadChoice {
+	choice := DownloadChoice{Host: host}
+	chosen := -1
+	for i, download := range downloads {
+		if reason := download.fits(host); reason != "" {
+			continue
+		}
+		if chosen == -1 || download.rank(host) > downloads[chosen].rank(host) {
+			chosen = i
+		}
+	}
+	for i, download := range downloads {
+		if i == chosen {
+			continue
+		}
+		reason := download.fits(host)
+		if reason == "" {
+			reason = "the chosen download suits this system better"
+		}
+		choice.Rejected = append(choice.Rejected, RejectedDownload{Download: download, Reason: reason})
+	}
+	if chosen != -1 {
+		choice.Download = downloads[chosen]
+		choice.Found = true
+	}
+	return choice
+}
@@ -0,0 +1,91 @@
+package plugin
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSelectDownload(t *testing.T) {
+	glibcV3 := Host{OS: "linux", Arch: "amd64", Libc: LibcGlibc, AMD64Level: 3}
+	muslV2 := Host{OS: "linux", Arch: "amd64", Libc: LibcMusl, AMD64Level: 2}
+	downloads := []Download{
+		{OS: AnyPlatform, Arch: AnyPlatform, Url: "any", Type: "wasm"},
+		{OS: "linux", Arch: "amd64", Url: "linux"},
+		{OS: "linux", Arch: "amd64", Libc: LibcGlibc, Url: "glibc"},
+		{OS: "linux", Arch: "amd64", Libc: LibcGlibc, GOAMD64: "v3", Url: "glibc-v3"},
+		{OS: "linux", Arch: "amd64", Libc: LibcMusl, Url: "musl"},
+		{OS: "windows", Arch: "amd64", Url: "windows"},
+	}
+	tests := map[string]struct {
+		host      Host
+		downloads []Download
+		wantUrl   string
+		wantFound bool
+	}{
+		"glibc with v3":       {host: glibcV3, downloads: downloads, wantUrl: "glibc-v3", wantFound: true},
+		"musl":                {host: muslV2, downloads: downloads, wantUrl: "musl", wantFound: true},
+		"unknown libc":        {host: Host{OS: "linux", Arch: "amd64", AMD64Level: 1}, downloads: downloads, wantUrl: "linux", wantFound: true},
+		"exact before any":    {host: Host{OS: "windows", Arch: "amd64"}, downloads: downloads, wantUrl: "windows", wantFound: true},
+		"any":                 {host: Host{OS: "darwin", Arch: "arm64"}, downloads: downloads, wantUrl: "any", wantFound: true},
+		"first of equal rank": {host: muslV2, downloads: []Download{{OS: "linux", Arch: "amd64", Url: "first"}, {OS: "linux", Arch: "amd64", Url: "second"}}, wantUrl: "first", wantFound: true},
+		"nothing fits":        {host: muslV2, downloads: []Download{{OS: "linux", Arch: "amd64", Libc: LibcGlibc}, {OS: "linux", Arch: "amd64", GOAMD64: "v3"}}},
+		"no downloads":        {host: muslV2},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			choice := SelectDownload(tt.downloads, tt.host)
+			if choice.Found != tt.wantFound {
+				t.Fatalf("want found to be %v, got %v", tt.wantFound, choice.Found)
+			}
+			if choice.Download.Url != tt.wantUrl {
+				t.Fatalf("want download %v, got %v", tt.wantUrl, choice.Download.Url)
+			}
+			wantRejected := len(tt.downloads)
+			if tt.wantFound {
+				wantRejected--
+			}
+			if len(choice.Rejected) != wantRejected {
+				t.Fatalf("want every other download to be rejected, got %v", choice.Rejected)
+			}
+		})
+	}
+}
+
+func TestDownloadChoiceExplanation(t *testing.T) {
+	choice := SelectDownload([]Download{
+		{OS: "linux", Arch: "amd64", Libc: LibcGlibc},
+		{OS: "linux", Arch: "amd64", GOAMD64: "v3"},
+		{OS: "darwin", Arch: "arm64"},
+	}, Host{OS: "linux", Arch: "amd64", Libc: LibcMusl, AMD64Level: 2})
+	explanation := choice.String()
+	for _, want := range []string{
+		"None of the downloads work on linux/amd64 (musl, GOAMD64 v2)",
+		"linux/amd64 (glibc): needs glibc, this system uses musl",
+		"linux/amd64 (GOAMD64 v3): needs GOAMD64 v3, this CPU supports up to v2",
+		"darwin/arm64: built for darwin/arm64",
+	} {
+		if !strings.Contains(explanation, want) {
+			t.Fatalf("want explanation to contain %q, got\n%v", want, explanation)
+		}
+	}
+}
+
+func TestDownloadChoiceHasAlternatives(t *testing.T) {
+	host := Host{OS: "linux", Arch: "amd64", Libc: LibcGlibc, AMD64Level: 3}
+	tests := map[string]struct {
+		downloads []Download
+		want      bool
+	}{
+		"only other platforms": {downloads: []Download{{OS: "linux", Arch: "amd64"}, {OS: "darwin", Arch: "arm64"}, {OS: "windows", Arch: "amd64"}}},
+		"other libc":           {downloads: []Download{{OS: "linux", Arch: "amd64", Libc: LibcGlibc}, {OS: "linux", Arch: "amd64", Libc: LibcMusl}}, want: true},
+		"any platform":         {downloads: []Download{{OS: "linux", Arch: "amd64"}, {OS: AnyPlatform, Arch: AnyPlatform}}, want: true},
+		"single download":      {downloads: []Download{{OS: "linux", Arch: "amd64"}}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := SelectDownload(tt.downloads, host).HasAlternatives(); got != tt.want {
+				t.Fatalf("want %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
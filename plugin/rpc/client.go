@@ -0,0 +1,247 @@
+package rpc
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/MTVersionManager/mtvm/plugin/api"
+	"github.com/MTVersionManager/mtvmplugin"
+)
+
+// closeTimeout is how long Close waits for the plugin to exit before killing it
+const closeTimeout = 5 * time.Second
+
+// Client is the host side of the protocol. It implements mtvmplugin.Plugin by forwarding every call to a plugin process.
+type Client struct {
+	*process
+	// ctx is the context the calls made through the client are bound to, see Bind
+	ctx context.Context
+}
+
+// process is the plugin process, which is shared by a client and the clients returned by its Bind method
+type process struct {
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	encoder *json.Encoder
+	decoder *json.Decoder
+	// mu makes sure only one call is in flight at a time
+	mu sync.Mutex
+	// writeMu makes sure notifications sent during a call don't get mixed up with requests
+	writeMu      sync.Mutex
+	nextID       int
+	apiVersion   int
+	capabilities []string
+}
+
+// Start starts the plugin executable at path and performs the initialize handshake.
+// Returns an error wrapping ErrProtocolMismatch if the plugin speaks a different protocol version.
+func Start(path string) (*Client, error) {
+	cmd := exec.Command(path)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	err = cmd.Start()
+	if err != nil {
+		return nil, err
+	}
+	client := &Client{
+		process: &process{
+			cmd:     cmd,
+			stdin:   stdin,
+			encoder: json.NewEncoder(stdin),
+			decoder: json.NewDecoder(bufio.NewReader(stdout)),
+		},
+		ctx: context.Background(),
+	}
+	var result InitializeResult
+	err = client.call(MethodInitialize, InitializeParams{ProtocolVersion: ProtocolVersion}, &result, nil)
+	if err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+	if result.ProtocolVersion != ProtocolVersion {
+		_ = client.Close()
+		return nil, fmt.Errorf("%w: plugin uses version %v, mtvm uses version %v", ErrProtocolMismatch, result.ProtocolVersion, ProtocolVersion)
+	}
+	client.apiVersion = result.APIVersion
+	if client.apiVersion == 0 {
+		client.apiVersion = api.UndeclaredVersion
+	}
+	client.capabilities = result.Capabilities
+	return client, nil
+}
+
+// Supports reports whether the plugin said it supports a capability during the handshake
+func (c *Client) Supports(capability string) bool {
+	return slices.Contains(c.capabilities, capability)
+}
+
+// APIVersion returns the plugin API version the plugin reported during the handshake
+func (c *Client) APIVersion() int {
+	return c.apiVersion
+}
+
+// Bind returns a client for the same plugin process whose calls return once ctx is done.
+// A call that returned early is left to the plugin to finish, and its response is skipped by the next call.
+func (c *Client) Bind(ctx context.Context) mtvmplugin.Plugin {
+	return &Client{process: c.process, ctx: ctx}
+}
+
+// Close closes the plugin's stdin, which tells it to exit, and waits for it to do so.
+// It doesn't wait for the call in flight, and a plugin that doesn't exit within closeTimeout is killed,
+// which also ends a call that is still waiting for it.
+func (c *Client) Close() error {
+	c.writeMu.Lock()
+	err := c.stdin.Close()
+	c.writeMu.Unlock()
+	if err != nil {
+		return err
+	}
+	exited := make(chan error, 1)
+	go func() {
+		exited <- c.cmd.Wait()
+	}()
+	select {
+	case err = <-exited:
+		return err
+	case <-time.After(closeTimeout):
+		_ = c.cmd.Process.Kill()
+		<-exited
+		return fmt.Errorf("plugin didn't exit within %v and was killed", closeTimeout)
+	}
+}
+
+func (c *Client) write(msg message) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.encoder.Encode(msg)
+}
+
+// call sends a request and waits for its response, storing the result in result if it is not nil.
+// Progress notifications received while waiting are sent to progress, unless the context of the client is done.
+func (c *Client) call(method string, params, result any, progress chan float64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	rawParams, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	c.nextID++
+	id := c.nextID
+	err = c.write(message{
+		JSONRPC: jsonrpcVersion,
+		ID:      &id,
+		Method:  method,
+		Params:  rawParams,
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrPluginExited, err)
+	}
+	for {
+		var msg message
+		err = c.decoder.Decode(&msg)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return ErrPluginExited
+			}
+			return err
+		}
+		if msg.ID == nil {
+			if msg.Method == MethodProgress && progress != nil {
+				var progressParams ProgressParams
+				err = json.Unmarshal(msg.Params, &progressParams)
+				if err != nil {
+					return err
+				}
+				select {
+				case progress <- progressParams.Progress:
+				case <-c.ctx.Done():
+					return c.ctx.Err()
+				}
+			}
+			continue
+		}
+		// Responses to calls that returned before the plugin answered them are skipped
+		if *msg.ID < id {
+			continue
+		}
+		if *msg.ID != id {
+			return fmt.Errorf("got response with id %v while waiting for a response with id %v", *msg.ID, id)
+		}
+		if msg.Error != nil {
+			return msg.Error
+		}
+		if result == nil || len(msg.Result) == 0 {
+			return nil
+		}
+		return json.Unmarshal(msg.Result, result)
+	}
+}
+
+func (c *Client) Download(version string, progress chan float64) error {
+	return c.call(MethodDownload, DownloadParams{Version: version}, nil, progress)
+}
+
+func (c *Client) Install(installDir string) error {
+	return c.call(MethodInstall, InstallParams{InstallDir: installDir}, nil, nil)
+}
+
+func (c *Client) Use(installDir string, pathDir string) error {
+	return c.call(MethodUse, UseParams{InstallDir: installDir, PathDir: pathDir}, nil, nil)
+}
+
+func (c *Client) Remove(installDir string, pathDir string, inUse bool) error {
+	return c.call(MethodRemove, RemoveParams{InstallDir: installDir, PathDir: pathDir, InUse: inUse}, nil, nil)
+}
+
+func (c *Client) GetCurrentVersion(installDir string, pathDir string) (string, error) {
+	var version string
+	err := c.call(MethodGetCurrentVersion, GetCurrentVersionParams{InstallDir: installDir, PathDir: pathDir}, &version, nil)
+	return version, err
+}
+
+func (c *Client) GetLatestVersion() (string, error) {
+	var version string
+	err := c.call(MethodGetLatestVersion, nil, &version, nil)
+	return version, err
+}
+
+func (c *Client) ListVersions() ([]string, error) {
+	var versions []string
+	err := c.call(MethodListVersions, nil, &versions, nil)
+	return versions, err
+}
+
+func (c *Client) Env(installDir string) (map[string]string, error) {
+	var env map[string]string
+	err := c.call(MethodEnv, EnvParams{InstallDir: installDir}, &env, nil)
+	return env, err
+}
+
+func (c *Client) Verify(installDir string) error {
+	return c.call(MethodVerify, VerifyParams{InstallDir: installDir}, nil, nil)
+}
+
+// Cancel asks the plugin to abort the call that is currently running.
+// Unlike the other methods it doesn't wait for the running call to finish.
+func (c *Client) Cancel() {
+	_ = c.write(message{
+		JSONRPC: jsonrpcVersion,
+		Method:  MethodCancel,
+	})
+}
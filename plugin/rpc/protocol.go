@@ -0,0 +1,117 @@
+// Package rpc implements the out-of-process plugin protocol.
+//
+// A plugin is an executable that reads JSON-RPC 2.0 requests from stdin and writes responses to stdout,
+// one JSON document per line. The host starts every session with an "initialize" request,
+// and while a "download" request is running the plugin reports progress with "progress" notifications.
+// The host can send a "cancel" notification to abort the running request if the plugin supports api.CapabilityCanceller.
+// Plugin authors can use Serve to implement the plugin side of the protocol.
+package rpc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ProtocolVersion is the version of the protocol implemented by this package.
+// It is increased whenever a change is made that older plugins or hosts would not understand.
+const ProtocolVersion = 1
+
+const jsonrpcVersion = "2.0"
+
+// Method names used by the protocol
+const (
+	MethodInitialize        = "initialize"
+	MethodDownload          = "download"
+	MethodInstall           = "install"
+	MethodUse               = "use"
+	MethodRemove            = "remove"
+	MethodGetCurrentVersion = "getCurrentVersion"
+	MethodGetLatestVersion  = "getLatestVersion"
+	MethodListVersions      = "listVersions"
+	MethodEnv               = "env"
+	MethodVerify            = "verify"
+	MethodProgress          = "progress"
+	MethodCancel            = "cancel"
+)
+
+// Error codes defined by JSON-RPC 2.0 that are used by the protocol
+const (
+	CodeParseError     = -32700
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+var (
+	ErrProtocolMismatch = errors.New("plugin speaks a different protocol version")
+	ErrPluginExited     = errors.New("plugin exited unexpectedly")
+)
+
+// message is used for requests, responses and notifications
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      *int            `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Error is an error returned by a plugin
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("plugin error %v: %v", e.Code, e.Message)
+}
+
+type InitializeParams struct {
+	ProtocolVersion int `json:"protocolVersion"`
+}
+
+type InitializeResult struct {
+	ProtocolVersion int `json:"protocolVersion"`
+	// APIVersion is the plugin API version the plugin implements, see the api package
+	APIVersion int `json:"apiVersion"`
+	// Capabilities are the names of the optional capabilities the plugin supports, see the api package
+	Capabilities []string `json:"capabilities"`
+}
+
+type DownloadParams struct {
+	Version string `json:"version"`
+}
+
+type ProgressParams struct {
+	Progress float64 `json:"progress"`
+}
+
+type InstallParams struct {
+	InstallDir string `json:"installDir"`
+}
+
+type UseParams struct {
+	InstallDir string `json:"installDir"`
+	PathDir    string `json:"pathDir"`
+}
+
+type RemoveParams struct {
+	InstallDir string `json:"installDir"`
+	PathDir    string `json:"pathDir"`
+	InUse      bool   `json:"inUse"`
+}
+
+type GetCurrentVersionParams struct {
+	InstallDir string `json:"installDir"`
+	PathDir    string `json:"pathDir"`
+}
+
+type EnvParams struct {
+	InstallDir string `json:"installDir"`
+}
+
+type VerifyParams struct {
+	InstallDir string `json:"installDir"`
+}
@@ -0,0 +1,211 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MTVersionManager/mtvm/plugin/api"
+)
+
+const testPluginEnv = "MTVM_RPC_TEST_PLUGIN"
+
+// testPlugin is served by the test binary itself when testPluginEnv is set
+type testPlugin struct{}
+
+// canceled receives a value when the host cancels a call
+var canceled = make(chan struct{}, 1)
+
+func (testPlugin) Download(version string, progress chan float64) error {
+	if version == "slow" {
+		<-canceled
+		return errors.New("download canceled")
+	}
+	if version != "1.2.3" {
+		return errors.New("unknown version " + version)
+	}
+	progress <- 0.5
+	progress <- 1
+	return nil
+}
+
+func (testPlugin) Install(installDir string) error {
+	if installDir == "" {
+		return errors.New("no install directory")
+	}
+	return nil
+}
+
+func (testPlugin) Use(installDir string, pathDir string) error {
+	return nil
+}
+
+func (testPlugin) Remove(installDir string, pathDir string, inUse bool) error {
+	if inUse {
+		return errors.New("in use")
+	}
+	return nil
+}
+
+func (testPlugin) GetCurrentVersion(installDir string, pathDir string) (string, error) {
+	return installDir + "|" + pathDir, nil
+}
+
+func (testPlugin) GetLatestVersion() (string, error) {
+	return "1.2.3", nil
+}
+
+func (testPlugin) ListVersions() ([]string, error) {
+	return []string{"1.2.3", "1.2.2"}, nil
+}
+
+func (testPlugin) Cancel() {
+	select {
+	case canceled <- struct{}{}:
+	default:
+	}
+}
+
+func TestMain(m *testing.M) {
+	if os.Getenv(testPluginEnv) == "1" {
+		err := Serve(testPlugin{})
+		if err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func startTestPlugin(t *testing.T) *Client {
+	t.Setenv(testPluginEnv, "1")
+	client, err := Start(os.Args[0])
+	if err != nil {
+		t.Fatalf("want no error when starting plugin, got %v", err)
+	}
+	t.Cleanup(func() {
+		err := client.Close()
+		if err != nil {
+			t.Errorf("want no error when closing plugin, got %v", err)
+		}
+	})
+	return client
+}
+
+func TestGetLatestVersion(t *testing.T) {
+	client := startTestPlugin(t)
+	version, err := client.GetLatestVersion()
+	if err != nil {
+		t.Fatalf("want no error, got %v", err)
+	}
+	if version != "1.2.3" {
+		t.Fatalf("want version 1.2.3, got %v", version)
+	}
+}
+
+func TestAPIVersion(t *testing.T) {
+	client := startTestPlugin(t)
+	if got := client.APIVersion(); got != api.Version {
+		t.Fatalf("want API version %v, got %v", api.Version, got)
+	}
+}
+
+func TestGetCurrentVersionParams(t *testing.T) {
+	client := startTestPlugin(t)
+	version, err := client.GetCurrentVersion("install", "path")
+	if err != nil {
+		t.Fatalf("want no error, got %v", err)
+	}
+	if version != "install|path" {
+		t.Fatalf("want 'install|path', got '%v'", version)
+	}
+}
+
+func TestDownloadProgress(t *testing.T) {
+	client := startTestPlugin(t)
+	progress := make(chan float64)
+	errChannel := make(chan error)
+	go func() {
+		errChannel <- client.Download("1.2.3", progress)
+	}()
+	for _, want := range []float64{0.5, 1} {
+		if got := <-progress; got != want {
+			t.Fatalf("want progress %v, got %v", want, got)
+		}
+	}
+	err := <-errChannel
+	if err != nil {
+		t.Fatalf("want no error, got %v", err)
+	}
+}
+
+func TestPluginError(t *testing.T) {
+	client := startTestPlugin(t)
+	err := client.Remove("install", "path", true)
+	if err == nil {
+		t.Fatal("want error, got nil")
+	}
+	var rpcErr *Error
+	if !errors.As(err, &rpcErr) {
+		t.Fatalf("want *Error, got %T with content %v", err, err)
+	}
+	if !strings.Contains(rpcErr.Message, "in use") {
+		t.Fatalf("want message containing 'in use', got %v", rpcErr.Message)
+	}
+	// The plugin should still work after returning an error
+	err = client.Install("install")
+	if err != nil {
+		t.Fatalf("want no error after previous error, got %v", err)
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	client := startTestPlugin(t)
+	if !api.Supports(client, api.CapabilityVersionLister) {
+		t.Fatal("want plugin to support listing versions")
+	}
+	if api.Supports(client, api.CapabilityEnvProvider) {
+		t.Fatal("want plugin to not support providing environment variables")
+	}
+	versions, err := client.ListVersions()
+	if err != nil {
+		t.Fatalf("want no error, got %v", err)
+	}
+	if len(versions) != 2 || versions[0] != "1.2.3" {
+		t.Fatalf("want versions [1.2.3 1.2.2], got %v", versions)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	client := startTestPlugin(t)
+	errChannel := make(chan error)
+	go func() {
+		errChannel <- client.Download("slow", make(chan float64))
+	}()
+	client.Cancel()
+	err := <-errChannel
+	if err == nil || !strings.Contains(err.Error(), "canceled") {
+		t.Fatalf("want canceled error, got %v", err)
+	}
+}
+
+func TestCallAfterTimeout(t *testing.T) {
+	client := startTestPlugin(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	// Nobody reads the progress, like after the host gave up on the call
+	err := client.Bind(ctx).Download("1.2.3", make(chan float64))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("want context.DeadlineExceeded, got %v", err)
+	}
+	version, err := client.GetLatestVersion()
+	if err != nil {
+		t.Fatalf("want no error when calling again after a timeout, got %v", err)
+	}
+	if version != "1.2.3" {
+		t.Fatalf("want version 1.2.3, got %v", version)
+	}
+}
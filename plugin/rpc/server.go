@@ -0,0 +1,222 @@
+package rpc
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/MTVersionManager/mtvm/plugin/api"
+	"github.com/MTVersionManager/mtvmplugin"
+)
+
+// Serve serves plugin over stdin and stdout until stdin is closed.
+// It is meant to be called from the main function of a plugin executable.
+func Serve(plugin mtvmplugin.Plugin) error {
+	return ServeConn(plugin, os.Stdin, os.Stdout)
+}
+
+type server struct {
+	plugin  mtvmplugin.Plugin
+	encoder *json.Encoder
+	writeMu sync.Mutex
+}
+
+func (s *server) write(msg message) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return s.encoder.Encode(msg)
+}
+
+// ServeConn serves plugin by reading requests from r and writing responses to w until r is closed.
+// Requests are handled one at a time in the background, so notifications like cancel are read while a request is running.
+func ServeConn(plugin mtvmplugin.Plugin, r io.Reader, w io.Writer) error {
+	decoder := json.NewDecoder(bufio.NewReader(r))
+	s := &server{
+		plugin:  plugin,
+		encoder: json.NewEncoder(w),
+	}
+	requests := make(chan message)
+	workerErr := make(chan error, 1)
+	go func() {
+		for request := range requests {
+			err := s.respond(request)
+			if err != nil {
+				workerErr <- err
+				// Keep receiving so the reading loop doesn't block
+				for range requests {
+				}
+				return
+			}
+		}
+		workerErr <- nil
+	}()
+	for {
+		var request message
+		err := decoder.Decode(&request)
+		if err != nil {
+			close(requests)
+			workerResult := <-workerErr
+			if errors.Is(err, io.EOF) {
+				return workerResult
+			}
+			return s.write(message{
+				JSONRPC: jsonrpcVersion,
+				Error:   &Error{Code: CodeParseError, Message: err.Error()},
+			})
+		}
+		if request.ID == nil {
+			if canceller, ok := plugin.(api.Canceller); ok && request.Method == MethodCancel {
+				canceller.Cancel()
+			}
+			continue
+		}
+		requests <- request
+	}
+}
+
+// respond handles a request and writes the response
+func (s *server) respond(request message) error {
+	result, rpcErr := s.handle(request)
+	response := message{
+		JSONRPC: jsonrpcVersion,
+		ID:      request.ID,
+		Error:   rpcErr,
+	}
+	if rpcErr == nil {
+		var err error
+		response.Result, err = json.Marshal(result)
+		if err != nil {
+			response.Error = &Error{Code: CodeInternalError, Message: err.Error()}
+		}
+	}
+	return s.write(response)
+}
+
+// handle calls the plugin method a request is for and returns what should be sent back
+func (s *server) handle(request message) (any, *Error) {
+	var err error
+	var result any
+	switch request.Method {
+	case MethodInitialize:
+		apiVersion := api.Version
+		if versioned, ok := s.plugin.(api.Versioned); ok {
+			apiVersion = versioned.APIVersion()
+		}
+		return InitializeResult{
+			ProtocolVersion: ProtocolVersion,
+			APIVersion:      apiVersion,
+			Capabilities:    api.Capabilities(s.plugin),
+		}, nil
+	case MethodDownload:
+		var params DownloadParams
+		if rpcErr := unmarshalParams(request.Params, &params); rpcErr != nil {
+			return nil, rpcErr
+		}
+		err = s.download(params.Version)
+	case MethodInstall:
+		var params InstallParams
+		if rpcErr := unmarshalParams(request.Params, &params); rpcErr != nil {
+			return nil, rpcErr
+		}
+		err = s.plugin.Install(params.InstallDir)
+	case MethodUse:
+		var params UseParams
+		if rpcErr := unmarshalParams(request.Params, &params); rpcErr != nil {
+			return nil, rpcErr
+		}
+		err = s.plugin.Use(params.InstallDir, params.PathDir)
+	case MethodRemove:
+		var params RemoveParams
+		if rpcErr := unmarshalParams(request.Params, &params); rpcErr != nil {
+			return nil, rpcErr
+		}
+		err = s.plugin.Remove(params.InstallDir, params.PathDir, params.InUse)
+	case MethodGetCurrentVersion:
+		var params GetCurrentVersionParams
+		if rpcErr := unmarshalParams(request.Params, &params); rpcErr != nil {
+			return nil, rpcErr
+		}
+		result, err = s.plugin.GetCurrentVersion(params.InstallDir, params.PathDir)
+	case MethodGetLatestVersion:
+		result, err = s.plugin.GetLatestVersion()
+	case MethodListVersions:
+		lister, ok := s.plugin.(api.VersionLister)
+		if !ok {
+			return nil, methodNotFound(request.Method)
+		}
+		result, err = lister.ListVersions()
+	case MethodEnv:
+		provider, ok := s.plugin.(api.EnvProvider)
+		if !ok {
+			return nil, methodNotFound(request.Method)
+		}
+		var params EnvParams
+		if rpcErr := unmarshalParams(request.Params, &params); rpcErr != nil {
+			return nil, rpcErr
+		}
+		result, err = provider.Env(params.InstallDir)
+	case MethodVerify:
+		verifier, ok := s.plugin.(api.Verifier)
+		if !ok {
+			return nil, methodNotFound(request.Method)
+		}
+		var params VerifyParams
+		if rpcErr := unmarshalParams(request.Params, &params); rpcErr != nil {
+			return nil, rpcErr
+		}
+		err = verifier.Verify(params.InstallDir)
+	default:
+		return nil, methodNotFound(request.Method)
+	}
+	if err != nil {
+		return nil, &Error{Code: CodeInternalError, Message: err.Error()}
+	}
+	return result, nil
+}
+
+func methodNotFound(method string) *Error {
+	return &Error{Code: CodeMethodNotFound, Message: "unknown method " + method}
+}
+
+func unmarshalParams(rawParams json.RawMessage, params any) *Error {
+	err := json.Unmarshal(rawParams, params)
+	if err != nil {
+		return &Error{Code: CodeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+// download runs plugin.Download and forwards its progress to the host as notifications.
+// It only returns once every progress value has been sent, so they always arrive before the response.
+func (s *server) download(version string) error {
+	progress := make(chan float64)
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case value := <-progress:
+				params, err := json.Marshal(ProgressParams{Progress: value})
+				if err != nil {
+					continue
+				}
+				_ = s.write(message{
+					JSONRPC: jsonrpcVersion,
+					Method:  MethodProgress,
+					Params:  params,
+				})
+			case <-done:
+				return
+			}
+		}
+	}()
+	err := s.plugin.Download(version, progress)
+	close(done)
+	wg.Wait()
+	return err
+}
@@ -0,0 +1,111 @@
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/MTVersionManager/mtvm/config"
+	"github.com/spf13/afero"
+)
+
+// SchemaVersion is the version of the format of plugins.json written by this version of mtvm.
+// Version 0 is the bare array of entries written by older versions, which is still read.
+const SchemaVersion = 1
+
+// ErrUnsupportedSchema is returned if plugins.json has a schema version this version of mtvm doesn't know, like one written by a newer mtvm
+var ErrUnsupportedSchema = errors.New("unsupported plugins.json schema version")
+
+// entriesDocument is the content of plugins.json
+type entriesDocument struct {
+	SchemaVersion int     `json:"schemaVersion"`
+	Plugins       []Entry `json:"plugins"`
+}
+
+// entriesPath returns the path of plugins.json
+func entriesPath() (string, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "plugins.json"), nil
+}
+
+// parseEntries parses plugins.json in any schema version up to SchemaVersion, returning the entries and the schema version of the data
+func parseEntries(data []byte) ([]Entry, int, error) {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		var entries []Entry
+		err := json.Unmarshal(data, &entries)
+		return entries, 0, err
+	}
+	var document entriesDocument
+	err := json.Unmarshal(data, &document)
+	if err != nil {
+		return nil, 0, err
+	}
+	if document.SchemaVersion < 1 || document.SchemaVersion > SchemaVersion {
+		return nil, document.SchemaVersion, fmt.Errorf("%w: %v, this version of mtvm supports up to %v", ErrUnsupportedSchema, document.SchemaVersion, SchemaVersion)
+	}
+	return document.Plugins, document.SchemaVersion, nil
+}
+
+// readEntries reads plugins.json, returning an error os.IsNotExist reports true for if it doesn't exist
+func readEntries(fs afero.Fs) ([]Entry, int, error) {
+	path, err := entriesPath()
+	if err != nil {
+		return nil, 0, err
+	}
+	data, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return nil, 0, err
+	}
+	return parseEntries(data)
+}
+
+// writeEntries writes the entries to plugins.json in the current schema version.
+// If the file it replaces has an older schema version, a copy of it is kept as plugins.json.v<version>.bak.
+// plugins.json has to be locked with lockEntries.
+func writeEntries(fs afero.Fs, entries []Entry, previousSchemaVersion int) error {
+	path, err := entriesPath()
+	if err != nil {
+		return err
+	}
+	if previousSchemaVersion < SchemaVersion {
+		data, err := afero.ReadFile(fs, path)
+		if err == nil {
+			err = writeFileAtomic(fs, fmt.Sprintf("%v.v%v.bak", path, previousSchemaVersion), data)
+		}
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if entries == nil {
+		entries = []Entry{}
+	}
+	data, err := json.MarshalIndent(entriesDocument{SchemaVersion: SchemaVersion, Plugins: entries}, "", "	")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(fs, path, data)
+}
+
+// MigrateEntries upgrades plugins.json to the current schema version, keeping a backup of the old file.
+// It returns true if plugins.json was migrated, and false if it doesn't exist or was up to date.
+func MigrateEntries(fs afero.Fs) (bool, error) {
+	unlock, err := lockEntries(fs)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+	entries, schemaVersion, err := readEntries(fs)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil || schemaVersion == SchemaVersion {
+		return false, err
+	}
+	return true, writeEntries(fs, entries, schemaVersion)
+}
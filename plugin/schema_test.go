@@ -0,0 +1,54 @@
+package plugin
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/MTVersionManager/mtvm/config"
+	"github.com/spf13/afero"
+)
+
+func TestMigrateEntries(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	createAndWritePluginsJson(t, []byte(twoEntryJson), fs)
+	migrated, err := MigrateEntries(fs)
+	if err != nil {
+		t.Fatalf("want no error, got %v", err)
+	}
+	if !migrated {
+		t.Fatal("want plugins.json in the old format to be migrated")
+	}
+	data := readPluginsJson(t, fs)
+	if string(data) != twoEntryDocument {
+		t.Fatalf("want plugins.json to contain\n%v\ngot plugins.json containing\n%v", twoEntryDocument, string(data))
+	}
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		t.Fatalf("want no error when getting config directory, got %v", err)
+	}
+	backup, err := afero.ReadFile(fs, filepath.Join(configDir, "plugins.json.v0.bak"))
+	if err != nil {
+		t.Fatalf("want no error when reading the backup, got %v", err)
+	}
+	if string(backup) != twoEntryJson {
+		t.Fatalf("want backup to contain\n%v\ngot backup containing\n%v", twoEntryJson, string(backup))
+	}
+
+	migrated, err = MigrateEntries(fs)
+	if err != nil {
+		t.Fatalf("want no error when migrating again, got %v", err)
+	}
+	if migrated {
+		t.Fatal("want plugins.json in the current format to not be migrated again")
+	}
+}
+
+func TestMigrateEntriesWithoutPluginsJson(t *testing.T) {
+	migrated, err := MigrateEntries(afero.NewMemMapFs())
+	if err != nil {
+		t.Fatalf("want no error, got %v", err)
+	}
+	if migrated {
+		t.Fatal("want nothing to be migrated")
+	}
+}
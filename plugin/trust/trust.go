@@ -0,0 +1,170 @@
+// Package trust manages the keys plugin metadata can be signed with.
+//
+// Signed metadata names the ed25519 public key of its publisher in its publicKey field,
+// and the base64 encoded detached signature of the metadata JSON is served next to it with .sig appended to the url.
+// The trusted keys are stored in trust.json in the config directory.
+// The first time a plugin is installed its key is trusted on first use,
+// after that metadata for the plugin has to be signed with the key trusted for it.
+// A key trusted for one plugin isn't trusted for others, so publishers can't sign metadata for plugins that aren't theirs.
+package trust
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/MTVersionManager/mtvm/config"
+	"github.com/spf13/afero"
+)
+
+var (
+	ErrInvalidKey       = errors.New("public keys must be base64 encoded ed25519 keys")
+	ErrInvalidSignature = errors.New("signature of the plugin metadata is invalid")
+	ErrKeyNotFound      = errors.New("key not found in the trust store")
+	// ErrUntrustedKey is returned if metadata is signed by a key that is not trusted
+	ErrUntrustedKey = errors.New("plugin metadata is signed by a key that is not trusted")
+	// ErrKeyChanged is returned if metadata is signed by a different key than the one trusted for the plugin
+	ErrKeyChanged = errors.New("plugin metadata is signed by a different key than before")
+	// ErrUnsigned is returned if metadata is not signed although a key is trusted for the plugin
+	ErrUnsigned = errors.New("plugin metadata is not signed, but it was signed before")
+)
+
+// Key is a trusted public key
+type Key struct {
+	// Name is the name of the plugin the key is trusted for, a key trusted for several plugins is stored once for each of them
+	Name      string `json:"name"`
+	PublicKey string `json:"publicKey"`
+}
+
+// ID returns a short identifier of the key to show to users
+func (k Key) ID() string {
+	sum := sha256.Sum256([]byte(k.PublicKey))
+	return hex.EncodeToString(sum[:8])
+}
+
+// ParsePublicKey decodes a base64 encoded ed25519 public key
+func ParsePublicKey(publicKey string) (ed25519.PublicKey, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(publicKey))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, publicKey)
+	}
+	return key, nil
+}
+
+// Verify checks the base64 encoded signature of data against the base64 encoded public key
+func Verify(publicKey string, data, signature []byte) error {
+	key, err := ParsePublicKey(publicKey)
+	if err != nil {
+		return err
+	}
+	rawSignature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil || !ed25519.Verify(key, data, rawSignature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// Check decides whether signed metadata of a plugin can be trusted, publicKey is empty if the metadata is not signed.
+// It returns ErrUntrustedKey if the user has to be asked whether to trust the key for the plugin, even if it is trusted for another one,
+// and ErrKeyChanged or ErrUnsigned if the metadata can't be trusted because a different key is trusted for the plugin.
+// The signature has to be checked with Verify before.
+func Check(keys []Key, pluginName, publicKey string) error {
+	if publicKey != "" && slices.ContainsFunc(keys, func(key Key) bool { return key.Name == pluginName && key.PublicKey == publicKey }) {
+		return nil
+	}
+	if slices.ContainsFunc(keys, func(key Key) bool { return key.Name == pluginName }) {
+		if publicKey == "" {
+			return fmt.Errorf("%w: %v", ErrUnsigned, pluginName)
+		}
+		return fmt.Errorf("%w: %v", ErrKeyChanged, pluginName)
+	}
+	if publicKey == "" {
+		return nil
+	}
+	return ErrUntrustedKey
+}
+
+func storePath() (string, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "trust.json"), nil
+}
+
+// Keys returns the trusted keys, which is a nil slice if none were trusted yet
+func Keys(fs afero.Fs) ([]Key, error) {
+	path, err := storePath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := afero.ReadFile(fs, path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var keys []Key
+	err = json.Unmarshal(data, &keys)
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func save(keys []Key, fs afero.Fs) error {
+	path, err := storePath()
+	if err != nil {
+		return err
+	}
+	err = fs.MkdirAll(filepath.Dir(path), 0o777)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(keys, "", "	")
+	if err != nil {
+		return err
+	}
+	return afero.WriteFile(fs, path, data, 0o666)
+}
+
+// Add trusts a key, doing nothing if it is already trusted under the same name
+func Add(key Key, fs afero.Fs) error {
+	_, err := ParsePublicKey(key.PublicKey)
+	if err != nil {
+		return err
+	}
+	keys, err := Keys(fs)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(keys, key) {
+		return nil
+	}
+	return save(append(keys, key), fs)
+}
+
+// Remove stops trusting the keys with the given name or ID.
+// Returns ErrKeyNotFound if there are none.
+func Remove(nameOrID string, fs afero.Fs) error {
+	keys, err := Keys(fs)
+	if err != nil {
+		return err
+	}
+	remaining := slices.DeleteFunc(slices.Clone(keys), func(key Key) bool {
+		return key.Name == nameOrID || key.ID() == nameOrID
+	})
+	if len(remaining) == len(keys) {
+		return fmt.Errorf("%w: %v", ErrKeyNotFound, nameOrID)
+	}
+	return save(remaining, fs)
+}
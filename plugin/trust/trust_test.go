@@ -0,0 +1,84 @@
+package trust
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func generateKey(t *testing.T) (string, ed25519.PrivateKey) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("want no error when generating key, got %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(publicKey), privateKey
+}
+
+func TestVerify(t *testing.T) {
+	publicKey, privateKey := generateKey(t)
+	data := []byte(`{"name": "loremIpsum"}`)
+	signature := []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, data)) + "\n")
+	if err := Verify(publicKey, data, signature); err != nil {
+		t.Fatalf("want valid signature, got %v", err)
+	}
+	if err := Verify(publicKey, []byte(`{"name": "dolorSitAmet"}`), signature); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("want ErrInvalidSignature for changed data, got %v", err)
+	}
+	if err := Verify("notAKey", data, signature); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("want ErrInvalidKey, got %v", err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	trustedKey, _ := generateKey(t)
+	otherKey, _ := generateKey(t)
+	keys := []Key{{Name: "loremIpsum", PublicKey: trustedKey}, {Name: "consectetur", PublicKey: otherKey}}
+	tests := map[string]struct {
+		pluginName string
+		publicKey  string
+		want       error
+	}{
+		"trusted key":              {pluginName: "loremIpsum", publicKey: trustedKey},
+		"trusted key other plugin": {pluginName: "dolorSitAmet", publicKey: trustedKey, want: ErrUntrustedKey},
+		"unsigned new plugin":      {pluginName: "dolorSitAmet"},
+		"new key new plugin":       {pluginName: "dolorSitAmet", publicKey: otherKey, want: ErrUntrustedKey},
+		"changed key":              {pluginName: "loremIpsum", publicKey: otherKey, want: ErrKeyChanged},
+		"unsigned":                 {pluginName: "loremIpsum", want: ErrUnsigned},
+		"key of other plugin":      {pluginName: "consectetur", publicKey: trustedKey, want: ErrKeyChanged},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := Check(keys, tt.pluginName, tt.publicKey); !errors.Is(err, tt.want) {
+				t.Fatalf("want %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestAddRemove(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	publicKey, _ := generateKey(t)
+	key := Key{Name: "loremIpsum", PublicKey: publicKey}
+	if err := Add(key, fs); err != nil {
+		t.Fatalf("want no error when adding key, got %v", err)
+	}
+	if err := Add(key, fs); err != nil {
+		t.Fatalf("want no error when adding key again, got %v", err)
+	}
+	keys, err := Keys(fs)
+	if err != nil {
+		t.Fatalf("want no error when getting keys, got %v", err)
+	}
+	if len(keys) != 1 || keys[0] != key {
+		t.Fatalf("want only the added key, got %v", keys)
+	}
+	if err := Remove(key.ID(), fs); err != nil {
+		t.Fatalf("want no error when removing key by ID, got %v", err)
+	}
+	if err := Remove("loremIpsum", fs); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("want ErrKeyNotFound when removing it again, got %v", err)
+	}
+}
@@ -10,20 +10,65 @@ type NotFoundMsg struct {
 type VersionMsg string
 
 type Metadata struct {
-	Name      string     `json:"name" validate:"required"`
+	Name string `json:"name" validate:"required"`
+	// Version and Downloads describe the newest release, they can be left out if Releases is used.
+	// Versions of mtvm that don't know about Releases only see this release.
+	Version   string     `json:"version" validate:"required_without=Releases,omitempty,semver"`
+	Downloads []Download `json:"downloads" validate:"required_without=Releases,dive"`
+	// MinMtvmVersion and MaxMtvmVersion are the oldest and newest versions of mtvm the plugin works with, both optional
+	MinMtvmVersion string `json:"minMtvmVersion,omitempty" validate:"omitempty,semver"`
+	MaxMtvmVersion string `json:"maxMtvmVersion,omitempty" validate:"omitempty,semver"`
+	// PublicKey is the base64 encoded ed25519 key the metadata is signed with, see the trust package
+	PublicKey string `json:"publicKey,omitempty" validate:"omitempty,base64"`
+	// Releases are further releases of the plugin, see Metadata.Select
+	Releases []Release `json:"releases,omitempty" validate:"omitempty,dive"`
+	// Mirrors are further urls the metadata is published at, tried in order if the url it was installed from fails
+	Mirrors []string `json:"mirrors,omitempty" validate:"omitempty,dive,required"`
+}
+
+// Release is a release of a plugin listed in Metadata.Releases
+type Release struct {
 	Version   string     `json:"version" validate:"required,semver"`
 	Downloads []Download `json:"downloads" validate:"required,dive"`
+	// MinMtvmVersion and MaxMtvmVersion default to the ones of the metadata
+	MinMtvmVersion string `json:"minMtvmVersion,omitempty" validate:"omitempty,semver"`
+	MaxMtvmVersion string `json:"maxMtvmVersion,omitempty" validate:"omitempty,semver"`
 }
 
+// AnyPlatform can be used as the OS and Arch of a Download that works everywhere, like a wasm plugin
+const AnyPlatform = "any"
+
 type Download struct {
-	OS       string `json:"os" validate:"required"`
-	Arch     string `json:"arch" validate:"required"`
-	Url      string `json:"url" validate:"required,http_url"`
-	Checksum string `json:"checksum"`
+	OS   string `json:"os" validate:"required"`
+	Arch string `json:"arch" validate:"required"`
+	// Url is a http(s) url, or a path relative to the metadata, see Metadata.ResolveUrls
+	Url string `json:"url" validate:"required"`
+	// Mirrors are further urls of the same file, tried in order if Url fails, they can be relative like Url
+	Mirrors  []string `json:"mirrors,omitempty" validate:"omitempty,dive,required"`
+	Checksum string   `json:"checksum"`
+	// Type is the plugin type of the file, an empty type means shared.PluginTypeNative
+	Type string `json:"type" validate:"omitempty,oneof=native rpc wasm"`
+	// Libc is the C library a linux download is built against, empty if it doesn't matter
+	Libc string `json:"libc,omitempty" validate:"omitempty,oneof=glibc musl"`
+	// GOAMD64 is the minimum GOAMD64 level an amd64 download needs, like v3, empty for v1
+	GOAMD64 string `json:"goamd64,omitempty" validate:"omitempty,oneof=v1 v2 v3 v4"`
 }
 
 type Entry struct {
 	Name        string `json:"name" validate:"required"`
 	Version     string `json:"version" validate:"required,semver"`
-	MetadataUrl string `json:"metadataUrl" validate:"required,http_url"`
+	MetadataUrl string `json:"metadataUrl" validate:"required,url"`
+	// Constraint is the version or semver constraint the plugin was installed with, plugin update only installs versions matching it
+	Constraint string `json:"constraint,omitempty"`
+	// Checksum is the checksum of the installed plugin file, like sha256:<hex digest>, see FileChecksum
+	Checksum string `json:"checksum,omitempty"`
+	// APIVersion is the API version the installed plugin implements, found out when it was installed so listing plugins doesn't have to load them.
+	// It is 0 for plugins installed before it was recorded.
+	APIVersion int `json:"apiVersion,omitempty"`
+	// MetadataMirrors are the mirrors of the metadata, tried in order if MetadataUrl fails
+	MetadataMirrors []string `json:"metadataMirrors,omitempty" validate:"omitempty,dive,url"`
+	// MetadataMirror is the url the metadata was last loaded from, it is tried first next time
+	MetadataMirror string `json:"metadataMirror,omitempty"`
+	// Mirror is the url the plugin file was last downloaded from, a mirror on the same host is tried first next time
+	Mirror string `json:"mirror,omitempty"`
 }
@@ -1,75 +1,53 @@
 package plugin
 
 import (
-	"encoding/json"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"os"
-	"path/filepath"
 
 	"github.com/MTVersionManager/mtvm/shared"
 	"github.com/spf13/afero"
-
-	"github.com/MTVersionManager/mtvm/config"
 )
 
-// UpdateEntries updates the data of an entry if it exists, and adds an entry if it doesn't
+// UpdateEntries updates the data of an entry if it exists, and adds an entry if it doesn't.
+// Entries are matched by name only, because a plugin installed from another metadata url replaces the installed file.
+// plugins.json is locked while it is changed, so concurrent calls, even from other processes, don't lose entries.
 func UpdateEntries(entry Entry, fs afero.Fs) error {
-	configDir, err := config.GetConfigDir()
+	unlock, err := lockEntries(fs)
 	if err != nil {
 		return err
 	}
-	err = fs.MkdirAll(configDir, 0o666)
-	if err != nil {
+	defer unlock()
+	entries, schemaVersion, err := readEntries(fs)
+	if err != nil && !os.IsNotExist(err) {
 		return err
 	}
-	data, err := afero.ReadFile(fs, filepath.Join(configDir, "plugins.json"))
 	var entryExists bool
-	var entries []Entry
-	if !os.IsNotExist(err) {
-		if err != nil {
-			return err
-		}
-		err = json.Unmarshal(data, &entries)
-		if err != nil {
-			return err
-		}
-		for i, v := range entries {
-			if entry.Name == v.Name && entry.MetadataUrl == v.MetadataUrl {
-				entryExists = true
-				entries[i] = entry
-				break
-			}
+	for i, v := range entries {
+		if entry.Name == v.Name {
+			entryExists = true
+			entries[i] = entry
+			break
 		}
 	}
 	if !entryExists {
 		entries = append(entries, entry)
 	}
-	data, err = json.MarshalIndent(entries, "", "	")
-	if err != nil {
-		return err
-	}
-	return afero.WriteFile(fs, filepath.Join(configDir, "plugins.json"), data, 0o666)
+	return writeEntries(fs, entries, schemaVersion)
 }
 
 // InstalledVersion returns the current version of a plugin that is installed.
 // Returns an ErrNotFound if the version is not found.
 func InstalledVersion(pluginName string, fs afero.Fs) (string, error) {
-	configDir, err := config.GetConfigDir()
-	if err != nil {
-		return "", err
-	}
-	data, err := afero.ReadFile(fs, filepath.Join(configDir, "plugins.json"))
+	entries, _, err := readEntries(fs)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return "", ErrNotFound
 		}
 		return "", err
 	}
-	var entries []Entry
-	err = json.Unmarshal(data, &entries)
-	if err != nil {
-		return "", err
-	}
 	for _, v := range entries {
 		if v.Name == pluginName {
 			return v.Version, nil
@@ -79,43 +57,34 @@ func InstalledVersion(pluginName string, fs afero.Fs) (string, error) {
 }
 
 // GetEntries returns a list of installed plugins and an error
-// and returns a nil slice if plugins.json is not present or if there is an error
+// and returns a nil slice if plugins.json is not present or if there is an error.
+// Both the current format of plugins.json and the bare array written by older versions of mtvm are read.
 func GetEntries(fs afero.Fs) ([]Entry, error) {
-	configDir, err := config.GetConfigDir()
-	if err != nil {
-		return nil, err
-	}
-	data, err := afero.ReadFile(fs, filepath.Join(configDir, "plugins.json"))
+	entries, _, err := readEntries(fs)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil, nil
 		}
-	}
-	var entries []Entry
-	err = json.Unmarshal(data, &entries)
-	if err != nil {
 		return nil, err
 	}
 	return entries, nil
 }
 
+// RemoveEntry removes the entry of a plugin, locking plugins.json like UpdateEntries.
+// Returns ErrNotFound if there is none.
 func RemoveEntry(pluginName string, fs afero.Fs) error {
-	configDir, err := config.GetConfigDir()
+	unlock, err := lockEntries(fs)
 	if err != nil {
 		return err
 	}
-	data, err := afero.ReadFile(fs, filepath.Join(configDir, "plugins.json"))
+	defer unlock()
+	entries, schemaVersion, err := readEntries(fs)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return ErrNotFound
 		}
 		return err
 	}
-	var entries []Entry
-	err = json.Unmarshal(data, &entries)
-	if err != nil {
-		return err
-	}
 	if len(entries) == 0 {
 		return ErrNotFound
 	}
@@ -128,17 +97,81 @@ func RemoveEntry(pluginName string, fs afero.Fs) error {
 	if len(removed) == len(entries) {
 		return ErrNotFound
 	}
-	data, err = json.MarshalIndent(removed, "", "	")
+	return writeEntries(fs, removed, schemaVersion)
+}
+
+// Remove removes the plugin files of every plugin type installed for a plugin, and its backup so it can't be rolled back to.
+// Returns ErrNotFound if there were no plugin files.
+func Remove(pluginName string, fs afero.Fs) error {
+	removed := false
+	for _, pluginType := range shared.PluginTypes {
+		err := fs.Remove(shared.PluginFile(pluginName, pluginType))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		removed = true
+	}
+	err := removeBackup(pluginName, fs)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// WriteFile writes a plugin file that didn't need to be downloaded, like a manifest, to its TempFile for CommitFile to move into place
+func WriteFile(pluginName, pluginType string, data []byte, fs afero.Fs) error {
+	err := fs.MkdirAll(shared.Configuration.PluginDir, 0o777)
 	if err != nil {
 		return err
 	}
-	return afero.WriteFile(fs, filepath.Join(configDir, "plugins.json"), data, 0o666)
+	return afero.WriteFile(fs, TempFile(pluginName, pluginType), data, 0o666)
 }
 
-func Remove(pluginName string, fs afero.Fs) error {
-	err := fs.Remove(filepath.Join(shared.Configuration.PluginDir, pluginName+shared.LibraryExtension))
-	if os.IsNotExist(err) {
-		return ErrNotFound
+// SetupFile prepares a freshly downloaded plugin file to be loaded.
+// RPC plugins are made executable, and files of other plugin types are removed so shared.LoadPlugin doesn't pick them instead.
+func SetupFile(pluginName, pluginType string, fs afero.Fs) error {
+	pluginPath := shared.PluginFile(pluginName, pluginType)
+	if pluginType == shared.PluginTypeRPC {
+		err := fs.Chmod(pluginPath, 0o755)
+		if err != nil {
+			return err
+		}
+	}
+	for _, otherType := range shared.PluginTypes {
+		otherPath := shared.PluginFile(pluginName, otherType)
+		if otherPath == pluginPath {
+			continue
+		}
+		err := fs.Remove(otherPath)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// InstalledTypes returns the types of the plugin files installed for a plugin, in the order shared.LoadPlugin looks for them
+func InstalledTypes(pluginName string, fs afero.Fs) ([]string, error) {
+	return installedTypes(pluginName, fs, shared.PluginFile)
+}
+
+// FileChecksum returns the sha256 checksum of an installed plugin file in the format of Entry.Checksum
+func FileChecksum(pluginName, pluginType string, fs afero.Fs) (string, error) {
+	file, err := fs.Open(shared.PluginFile(pluginName, pluginType))
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	_, err = io.Copy(hash, file)
+	if err != nil {
+		return "", err
 	}
-	return err
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil)), nil
 }
@@ -33,6 +33,34 @@ var twoEntryJson string = `[
 	}
 ]`
 
+// oneEntryDocument and twoEntryDocument are oneEntryJson and twoEntryJson in the current schema version
+var oneEntryDocument string = `{
+	"schemaVersion": 1,
+	"plugins": [
+		{
+			"name": "loremIpsum",
+			"version": "0.0.0",
+			"metadataUrl": "https://example.com"
+		}
+	]
+}`
+
+var twoEntryDocument string = `{
+	"schemaVersion": 1,
+	"plugins": [
+		{
+			"name": "loremIpsum",
+			"version": "0.0.0",
+			"metadataUrl": "https://example.com"
+		},
+		{
+			"name": "dolorSitAmet",
+			"version": "0.0.0",
+			"metadataUrl": "https://example.com"
+		}
+	]
+}`
+
 func TestInstalledVersionNoPluginFile(t *testing.T) {
 	_, err := InstalledVersion("loremIpsum", afero.NewMemMapFs())
 	checkIfErrNotFound(t, err)
@@ -56,8 +84,8 @@ func TestAddFirstEntryNoPluginFile(t *testing.T) {
 		t.Fatalf("want no error, got %v", err)
 	}
 	data := readPluginsJson(t, fs)
-	if string(data) != oneEntryJson {
-		t.Fatalf("want plugins.json to contain\n%v\ngot plugins.json containing\n%v", oneEntryJson, string(data))
+	if string(data) != oneEntryDocument {
+		t.Fatalf("want plugins.json to contain\n%v\ngot plugins.json containing\n%v", oneEntryDocument, string(data))
 	}
 }
 
@@ -78,8 +106,8 @@ func TestUpdateEntryWithPluginsJson(t *testing.T) {
 			wantsError: false,
 			testFunc: func(t *testing.T, fs afero.Fs, err error) {
 				data := readPluginsJson(t, fs)
-				if string(data) != twoEntryJson {
-					t.Fatalf("want plugins.json to contain\n%v\ngot plugins.json containing\n%v", twoEntryJson, string(data))
+				if string(data) != twoEntryDocument {
+					t.Fatalf("want plugins.json to contain\n%v\ngot plugins.json containing\n%v", twoEntryDocument, string(data))
 				}
 			},
 		},
@@ -93,13 +121,41 @@ func TestUpdateEntryWithPluginsJson(t *testing.T) {
 			wantsError: false,
 			testFunc: func(t *testing.T, fs afero.Fs, err error) {
 				data := readPluginsJson(t, fs)
-				expected := `[
-	{
-		"name": "loremIpsum",
-		"version": "1.0.0",
-		"metadataUrl": "https://example.com"
-	}
-]`
+				expected := `{
+	"schemaVersion": 1,
+	"plugins": [
+		{
+			"name": "loremIpsum",
+			"version": "1.0.0",
+			"metadataUrl": "https://example.com"
+		}
+	]
+}`
+				if string(data) != expected {
+					t.Fatalf("want plugins.json to contain\n%v\ngot plugins.json containing\n%v", expected, string(data))
+				}
+			},
+		},
+		"update existing from another url": {
+			pluginsJsonContent: []byte(oneEntryJson),
+			entry: Entry{
+				Name:        "loremIpsum",
+				Version:     "1.0.0",
+				MetadataUrl: "https://example.org",
+			},
+			wantsError: false,
+			testFunc: func(t *testing.T, fs afero.Fs, err error) {
+				data := readPluginsJson(t, fs)
+				expected := `{
+	"schemaVersion": 1,
+	"plugins": [
+		{
+			"name": "loremIpsum",
+			"version": "1.0.0",
+			"metadataUrl": "https://example.org"
+		}
+	]
+}`
 				if string(data) != expected {
 					t.Fatalf("want plugins.json to contain\n%v\ngot plugins.json containing\n%v", expected, string(data))
 				}
@@ -135,6 +191,20 @@ func TestGetEntriesWithNoPluginsJson(t *testing.T) {
 }
 
 func TestGetEntriesWithPluginsJson(t *testing.T) {
+	testFuncTwoEntries := func(t *testing.T, entries []Entry, err error) {
+		if err != nil {
+			t.Fatalf("want no error, got %v", err)
+		}
+		if len(entries) != 2 {
+			t.Fatalf("want 2 entries, got %v entries containing %v", len(entries), entries)
+		}
+		if entries[0].Name != "loremIpsum" {
+			t.Fatalf("wanted first entry name to be 'loremIpsum', got %v", entries[0].Name)
+		}
+		if entries[1].Name != "dolorSitAmet" {
+			t.Fatalf("wanted second entry name to be 'dolorSitAmet', got %v", entries[1].Name)
+		}
+	}
 	tests := map[string]struct {
 		pluginsJsonContent []byte
 		testFunc           func(t *testing.T, entries []Entry, err error)
@@ -152,18 +222,17 @@ func TestGetEntriesWithPluginsJson(t *testing.T) {
 		},
 		"two entries": {
 			pluginsJsonContent: []byte(twoEntryJson),
+			testFunc:           testFuncTwoEntries,
+		},
+		"two entries in the current schema version": {
+			pluginsJsonContent: []byte(twoEntryDocument),
+			testFunc:           testFuncTwoEntries,
+		},
+		"newer schema version": {
+			pluginsJsonContent: []byte(`{"schemaVersion": 1000, "plugins": []}`),
 			testFunc: func(t *testing.T, entries []Entry, err error) {
-				if err != nil {
-					t.Fatalf("want no error, got %v", err)
-				}
-				if len(entries) != 2 {
-					t.Fatalf("want 2 entries, got %v entries containing %v", len(entries), entries)
-				}
-				if entries[0].Name != "loremIpsum" {
-					t.Fatalf("wanted first entry name to be 'loremIpsum', got %v", entries[0].Name)
-				}
-				if entries[1].Name != "dolorSitAmet" {
-					t.Fatalf("wanted second entry name to be 'dolorSitAmet', got %v", entries[1].Name)
+				if !errors.Is(err, ErrUnsupportedSchema) {
+					t.Fatalf("want ErrUnsupportedSchema, got %v", err)
 				}
 			},
 		},
@@ -202,8 +271,8 @@ func TestRemoveEntryWithPluginsJson(t *testing.T) {
 					t.Fatalf("want no error, got %v", err)
 				}
 				data := readPluginsJson(t, fs)
-				if string(data) != oneEntryJson {
-					t.Fatalf("want plugins.json to contain\n%v\ngot plugins.json containing\n%v", oneEntryJson, string(data))
+				if string(data) != oneEntryDocument {
+					t.Fatalf("want plugins.json to contain\n%v\ngot plugins.json containing\n%v", oneEntryDocument, string(data))
 				}
 			},
 		},
@@ -252,7 +321,7 @@ func TestRemoveExisting(t *testing.T) {
 	if err != nil {
 		t.Fatalf("want no error when creating plugin directory, got %v", err)
 	}
-	pluginPath := filepath.Join(shared.Configuration.PluginDir, "loremIpsum"+shared.LibraryExtension)
+	pluginPath := shared.PluginFile("loremIpsum", shared.PluginTypeNative)
 	_, err = fs.Create(pluginPath)
 	if err != nil {
 		t.Fatalf("want no error when creating plugin file, got %v", err)
@@ -276,6 +345,32 @@ func TestRemoveNonExistent(t *testing.T) {
 	checkIfErrNotFound(t, err)
 }
 
+func TestSetupFileRemovesOtherTypes(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	nativePath := shared.PluginFile("loremIpsum", shared.PluginTypeNative)
+	rpcPath := shared.PluginFile("loremIpsum", shared.PluginTypeRPC)
+	for _, pluginPath := range []string{nativePath, rpcPath} {
+		err := afero.WriteFile(fs, pluginPath, []byte{}, 0o666)
+		if err != nil {
+			t.Fatalf("want no error when creating plugin file, got %v", err)
+		}
+	}
+	err := SetupFile("loremIpsum", shared.PluginTypeRPC, fs)
+	if err != nil {
+		t.Fatalf("want no error, got %v", err)
+	}
+	if _, err = fs.Stat(nativePath); !os.IsNotExist(err) {
+		t.Fatalf("want native plugin file to be removed, got %v (stat)", err)
+	}
+	info, err := fs.Stat(rpcPath)
+	if err != nil {
+		t.Fatalf("want no error, got %v (stat)", err)
+	}
+	if info.Mode().Perm()&0o100 == 0 {
+		t.Fatalf("want rpc plugin file to be executable, got mode %v", info.Mode())
+	}
+}
+
 func createAndWritePluginsJson(t *testing.T, content []byte, fs afero.Fs) {
 	configDir, err := config.GetConfigDir()
 	if err != nil {
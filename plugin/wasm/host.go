@@ -0,0 +1,440 @@
+package wasm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/MTVersionManager/mtvm/plugin/manifest"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+)
+
+const (
+	rootInstall = "install"
+	rootPath    = "path"
+)
+
+var (
+	ErrPathNotAllowed = errors.New("plugin tried to access a path outside of the directories it can access")
+	ErrNoDownload     = errors.New("plugin tried to use a download before downloading anything")
+)
+
+// Host function results
+const (
+	statusOk    uint32 = 0
+	statusError uint32 = 1
+)
+
+// hostModule builds the "mtvm" module with the functions plugins use to access the outside world
+func (p *Plugin) hostModule() wazero.HostModuleBuilder {
+	builder := p.runtime.NewHostModuleBuilder(hostModuleName)
+	builder.NewFunctionBuilder().WithFunc(p.setError).Export("set_error")
+	builder.NewFunctionBuilder().WithFunc(p.reportProgress).Export("progress")
+	builder.NewFunctionBuilder().WithFunc(p.httpGet).Export("http_get")
+	builder.NewFunctionBuilder().WithFunc(p.download).Export("download")
+	builder.NewFunctionBuilder().WithFunc(p.saveDownload).Export("save_download")
+	builder.NewFunctionBuilder().WithFunc(p.extractDownload).Export("extract_download")
+	builder.NewFunctionBuilder().WithFunc(p.mkdirAll).Export("mkdir_all")
+	builder.NewFunctionBuilder().WithFunc(p.removeAll).Export("remove_all")
+	builder.NewFunctionBuilder().WithFunc(p.writeFile).Export("write_file")
+	builder.NewFunctionBuilder().WithFunc(p.readFile).Export("read_file")
+	builder.NewFunctionBuilder().WithFunc(p.symlink).Export("symlink")
+	builder.NewFunctionBuilder().WithFunc(p.readlink).Export("readlink")
+	return builder
+}
+
+// resolve turns a path used by a plugin into a path on the host, following the symlinks in it.
+// Returns an error wrapping ErrPathNotAllowed if the path, or where its symlinks lead, is not inside a directory the current call can access.
+func (p *Plugin) resolve(pluginPath string) (string, error) {
+	hostPath, err := p.resolveLexically(pluginPath)
+	if err != nil {
+		return "", err
+	}
+	return p.checkRealPath(hostPath)
+}
+
+// resolveLink is like resolve, but doesn't follow the last element of the path, so links themselves can be created, read and removed
+func (p *Plugin) resolveLink(pluginPath string) (string, error) {
+	hostPath, err := p.resolveLexically(pluginPath)
+	if err != nil {
+		return "", err
+	}
+	if hostPath == p.installRoot || hostPath == p.pathRoot {
+		return p.checkRealPath(hostPath)
+	}
+	dir, err := p.checkRealPath(filepath.Dir(hostPath))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, filepath.Base(hostPath)), nil
+}
+
+// checkRealPath returns where hostPath really is once its symlinks are followed,
+// with an error wrapping ErrPathNotAllowed if that is outside of the directories the current call can access
+func (p *Plugin) checkRealPath(hostPath string) (string, error) {
+	real, err := realPath(hostPath)
+	if err != nil {
+		return "", err
+	}
+	for _, base := range []string{p.installRoot, p.pathRoot} {
+		if base == "" {
+			continue
+		}
+		realBase, err := realPath(base)
+		if err != nil {
+			return "", err
+		}
+		if isInside(realBase, real) {
+			return real, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %v leads to %v", ErrPathNotAllowed, hostPath, real)
+}
+
+// realPath follows the symlinks in the part of hostPath that exists.
+// A symlink pointing to something that doesn't exist can't be followed, so it is an error.
+func realPath(hostPath string) (string, error) {
+	existing := hostPath
+	var missing []string
+	for {
+		resolved, err := filepath.EvalSymlinks(existing)
+		if err == nil {
+			return filepath.Join(append([]string{resolved}, missing...)...), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		if _, err := os.Lstat(existing); err == nil {
+			return "", fmt.Errorf("%w: %v is a broken symlink", ErrPathNotAllowed, existing)
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return hostPath, nil
+		}
+		missing = append([]string{filepath.Base(existing)}, missing...)
+		existing = parent
+	}
+}
+
+// isInside reports whether hostPath is base or inside of it
+func isInside(base, hostPath string) bool {
+	relative, err := filepath.Rel(base, hostPath)
+	return err == nil && relative != ".." && !strings.HasPrefix(relative, ".."+string(filepath.Separator))
+}
+
+// resolveLexically turns a path used by a plugin into a path on the host without looking at the file system
+func (p *Plugin) resolveLexically(pluginPath string) (string, error) {
+	root, rest, _ := strings.Cut(pluginPath, "/")
+	var base string
+	switch root {
+	case rootInstall:
+		base = p.installRoot
+	case rootPath:
+		base = p.pathRoot
+	}
+	cleaned := path.Clean(rest)
+	if base == "" || path.IsAbs(rest) || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("%w: %v", ErrPathNotAllowed, pluginPath)
+	}
+	return filepath.Join(base, filepath.FromSlash(cleaned)), nil
+}
+
+// unresolve turns a path on the host into a path a plugin can use
+func (p *Plugin) unresolve(hostPath string) (string, error) {
+	for _, root := range []struct {
+		name string
+		base string
+	}{{rootInstall, p.installRoot}, {rootPath, p.pathRoot}} {
+		if root.base == "" {
+			continue
+		}
+		base := root.base
+		// Resolved paths are inside the real root, which is different if the root is reached through a symlink
+		if realBase, err := realPath(base); err == nil && isInside(realBase, hostPath) {
+			base = realBase
+		}
+		if !isInside(base, hostPath) {
+			continue
+		}
+		relative, err := filepath.Rel(base, hostPath)
+		if err != nil {
+			continue
+		}
+		if relative == "." {
+			return root.name, nil
+		}
+		return root.name + "/" + filepath.ToSlash(relative), nil
+	}
+	return "", fmt.Errorf("%w: %v", ErrPathNotAllowed, hostPath)
+}
+
+func readString(m api.Module, ptr, length uint32) (string, bool) {
+	data, ok := m.Memory().Read(ptr, length)
+	return string(data), ok
+}
+
+// returnBytes copies data into memory allocated by the plugin and returns it the same way plugins return strings
+func (p *Plugin) returnBytes(ctx context.Context, m api.Module, data []byte) uint64 {
+	results, err := m.ExportedFunction("mtvm_alloc").Call(ctx, uint64(len(data)))
+	if err != nil {
+		p.fail(err)
+		return 0
+	}
+	ptr := uint32(results[0])
+	if !m.Memory().Write(ptr, data) {
+		p.fail(errors.New("mtvm_alloc returned memory outside of memory"))
+		return 0
+	}
+	return uint64(ptr)<<32 | uint64(len(data))
+}
+
+// resolveParam reads a path from memory and resolves it, recording the error if that fails
+func (p *Plugin) resolveParam(m api.Module, ptr, length uint32) (string, bool) {
+	return p.resolveParamWith(p.resolve, m, ptr, length)
+}
+
+// resolveLinkParam is resolveParam for paths of links, see resolveLink
+func (p *Plugin) resolveLinkParam(m api.Module, ptr, length uint32) (string, bool) {
+	return p.resolveParamWith(p.resolveLink, m, ptr, length)
+}
+
+func (p *Plugin) resolveParamWith(resolve func(string) (string, error), m api.Module, ptr, length uint32) (string, bool) {
+	pluginPath, ok := readString(m, ptr, length)
+	if !ok {
+		p.fail(errors.New("path is outside of memory"))
+		return "", false
+	}
+	hostPath, err := resolve(pluginPath)
+	if err != nil {
+		p.fail(err)
+		return "", false
+	}
+	return hostPath, true
+}
+
+// status turns an error into a host function result, recording the error
+func (p *Plugin) status(err error) uint32 {
+	if err != nil {
+		p.fail(err)
+		return statusError
+	}
+	return statusOk
+}
+
+func (p *Plugin) setError(_ context.Context, m api.Module, ptr, length uint32) {
+	message, ok := readString(m, ptr, length)
+	if ok {
+		p.fail(errors.New(message))
+	}
+}
+
+func (p *Plugin) reportProgress(value float64) {
+	// Like with manifest.ProgressWriter, the host sends 1 itself once the download function of the plugin returns
+	if p.progress != nil && value < 1 {
+		p.progress <- value
+	}
+}
+
+// get requests url, giving up once the call is stopped by ctx
+func (p *Plugin) get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%v %v", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+	return resp, nil
+}
+
+func (p *Plugin) httpGet(ctx context.Context, m api.Module, urlPtr, urlLen uint32) uint64 {
+	url, ok := readString(m, urlPtr, urlLen)
+	if !ok {
+		p.fail(errors.New("url is outside of memory"))
+		return 0
+	}
+	resp, err := p.get(ctx, url)
+	if err != nil {
+		p.fail(err)
+		return 0
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		p.fail(err)
+		return 0
+	}
+	return p.returnBytes(ctx, m, data)
+}
+
+func (p *Plugin) download(ctx context.Context, m api.Module, urlPtr, urlLen uint32) uint32 {
+	url, ok := readString(m, urlPtr, urlLen)
+	if !ok {
+		return p.status(errors.New("url is outside of memory"))
+	}
+	resp, err := p.get(ctx, url)
+	if err != nil {
+		return p.status(err)
+	}
+	defer resp.Body.Close()
+	if p.scratchDir == "" {
+		p.scratchDir, err = os.MkdirTemp("", "mtvm-wasm-*")
+		if err != nil {
+			return p.status(err)
+		}
+	}
+	file, err := os.Create(filepath.Join(p.scratchDir, "download"))
+	if err != nil {
+		return p.status(err)
+	}
+	defer file.Close()
+	_, err = io.Copy(file, io.TeeReader(resp.Body, &manifest.ProgressWriter{
+		TotalSize: resp.ContentLength,
+		Progress:  p.progress,
+	}))
+	if err != nil {
+		return p.status(err)
+	}
+	p.downloadedFile = file.Name()
+	return statusOk
+}
+
+func (p *Plugin) saveDownload(_ context.Context, m api.Module, pathPtr, pathLen uint32) uint32 {
+	target, ok := p.resolveParam(m, pathPtr, pathLen)
+	if !ok {
+		return statusError
+	}
+	if p.downloadedFile == "" {
+		return p.status(ErrNoDownload)
+	}
+	err := os.MkdirAll(filepath.Dir(target), 0o755)
+	if err != nil {
+		return p.status(err)
+	}
+	source, err := os.Open(p.downloadedFile)
+	if err != nil {
+		return p.status(err)
+	}
+	defer source.Close()
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o755)
+	if err != nil {
+		return p.status(err)
+	}
+	_, err = io.Copy(file, source)
+	if err != nil {
+		file.Close()
+		return p.status(err)
+	}
+	return p.status(file.Close())
+}
+
+func (p *Plugin) extractDownload(_ context.Context, m api.Module, archivePtr, archiveLen, pathPtr, pathLen, stripComponents uint32) uint32 {
+	archive, ok := readString(m, archivePtr, archiveLen)
+	if !ok {
+		return p.status(errors.New("archive type is outside of memory"))
+	}
+	target, ok := p.resolveParam(m, pathPtr, pathLen)
+	if !ok {
+		return statusError
+	}
+	if p.downloadedFile == "" {
+		return p.status(ErrNoDownload)
+	}
+	return p.status(manifest.Extract(archive, p.downloadedFile, target, int(stripComponents)))
+}
+
+func (p *Plugin) mkdirAll(_ context.Context, m api.Module, pathPtr, pathLen uint32) uint32 {
+	target, ok := p.resolveParam(m, pathPtr, pathLen)
+	if !ok {
+		return statusError
+	}
+	return p.status(os.MkdirAll(target, 0o755))
+}
+
+func (p *Plugin) removeAll(_ context.Context, m api.Module, pathPtr, pathLen uint32) uint32 {
+	target, ok := p.resolveLinkParam(m, pathPtr, pathLen)
+	if !ok {
+		return statusError
+	}
+	return p.status(os.RemoveAll(target))
+}
+
+func (p *Plugin) writeFile(_ context.Context, m api.Module, pathPtr, pathLen, dataPtr, dataLen uint32) uint32 {
+	target, ok := p.resolveParam(m, pathPtr, pathLen)
+	if !ok {
+		return statusError
+	}
+	data, ok := m.Memory().Read(dataPtr, dataLen)
+	if !ok {
+		return p.status(errors.New("data is outside of memory"))
+	}
+	return p.status(os.WriteFile(target, data, 0o644))
+}
+
+func (p *Plugin) readFile(ctx context.Context, m api.Module, pathPtr, pathLen uint32) uint64 {
+	target, ok := p.resolveParam(m, pathPtr, pathLen)
+	if !ok {
+		return 0
+	}
+	data, err := os.ReadFile(target)
+	if err != nil {
+		p.fail(err)
+		return 0
+	}
+	return p.returnBytes(ctx, m, data)
+}
+
+// symlink creates a link to target, which is resolved first so the link can't lead outside of the directories the plugin can access
+func (p *Plugin) symlink(_ context.Context, m api.Module, targetPtr, targetLen, linkPtr, linkLen uint32) uint32 {
+	target, ok := p.resolveParam(m, targetPtr, targetLen)
+	if !ok {
+		return statusError
+	}
+	link, ok := p.resolveLinkParam(m, linkPtr, linkLen)
+	if !ok {
+		return statusError
+	}
+	err := os.Remove(link)
+	if err != nil && !os.IsNotExist(err) {
+		return p.status(err)
+	}
+	return p.status(os.Symlink(target, link))
+}
+
+// readlink returns the target of a link as a path the plugin can use.
+// If the link doesn't exist it returns an empty string, so plugins can tell that apart from a failure.
+func (p *Plugin) readlink(ctx context.Context, m api.Module, linkPtr, linkLen uint32) uint64 {
+	link, ok := p.resolveLinkParam(m, linkPtr, linkLen)
+	if !ok {
+		return 0
+	}
+	target, err := os.Readlink(link)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return p.returnBytes(ctx, m, nil)
+		}
+		p.fail(err)
+		return 0
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(link), target)
+	}
+	pluginPath, err := p.unresolve(target)
+	if err != nil {
+		p.fail(err)
+		return 0
+	}
+	return p.returnBytes(ctx, m, []byte(pluginPath))
+}
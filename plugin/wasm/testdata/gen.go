@@ -0,0 +1,221 @@
+//go:build ignore
+
+// gen writes plugin.wasm, the module used by the wasm package's tests.
+// The module is encoded by hand to keep it tiny, this is what it does in the text format:
+//
+//	(module
+//	  (import "mtvm" "download" (func $download (param i32 i32) (result i32)))
+//	  (import "mtvm" "save_download" (func $save_download (param i32 i32) (result i32)))
+//	  (import "mtvm" "symlink" (func $symlink (param i32 i32 i32 i32) (result i32)))
+//	  (import "mtvm" "remove_all" (func $remove_all (param i32 i32) (result i32)))
+//	  (import "mtvm" "readlink" (func $readlink (param i32 i32) (result i64)))
+//	  (memory (export "memory") 1)
+//	  (global $heap (mut i32) (i32.const 1024))
+//	  (data (i32.const 0) "1.2.3")
+//	  (data (i32.const 16) "http://mtvm.test/tool")
+//	  (data (i32.const 48) "install/tool")
+//	  (data (i32.const 64) "path/tool")
+//	  (func (export "mtvm_alloc") (param $size i32) (result i32)
+//	    global.get $heap
+//	    (global.set $heap (i32.add (global.get $heap) (local.get $size))))
+//	  (func (export "mtvm_get_latest_version") (result i64)
+//	    i64.const 5)
+//	  (func (export "mtvm_download") (param i32 i32) (result i32)
+//	    (call $download (i32.const 16) (i32.const 21)))
+//	  (func (export "mtvm_install") (result i32)
+//	    (call $save_download (i32.const 48) (i32.const 12)))
+//	  (func (export "mtvm_use") (result i32)
+//	    (call $symlink (i32.const 48) (i32.const 12) (i32.const 64) (i32.const 9)))
+//	  (func (export "mtvm_remove") (param $inUse i32) (result i32)
+//	    (if (local.get $inUse)
+//	      (then (if (call $remove_all (i32.const 64) (i32.const 9)) (then (return (i32.const 1))))))
+//	    (call $remove_all (i32.const 48) (i32.const 7)))
+//	  ;; readlink returns "install/<version>/tool", so the version starts 8 bytes in and is 13 bytes shorter
+//	  (func (export "mtvm_get_current_version") (result i64) (local $target i64)
+//	    (if (i64.eqz (local.tee $target (call $readlink (i32.const 64) (i32.const 9))))
+//	      (then (return (i64.const 0))))
+//	    (i64.add (local.get $target) (i64.const 34359738355)))
+//	  (func (export "mtvm_api_version") (result i32)
+//	    i32.const 1)
+//	  ;; verify never returns, so calling it tests that calls stop once their context is done
+//	  (func (export "mtvm_verify") (result i32)
+//	    (loop (br 0))
+//	    i32.const 0))
+package main
+
+import (
+	"log"
+	"os"
+)
+
+const (
+	i32 = 0x7f
+	i64 = 0x7e
+)
+
+func uleb(v uint64) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if v != 0 {
+			out = append(out, b|0x80)
+			continue
+		}
+		return append(out, b)
+	}
+}
+
+func sleb(v int64) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && b&0x40 == 0) || (v == -1 && b&0x40 != 0) {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+func name(s string) []byte {
+	return append(uleb(uint64(len(s))), s...)
+}
+
+func vector(items ...[]byte) []byte {
+	out := uleb(uint64(len(items)))
+	for _, item := range items {
+		out = append(out, item...)
+	}
+	return out
+}
+
+func section(id byte, content []byte) []byte {
+	return append(append([]byte{id}, uleb(uint64(len(content)))...), content...)
+}
+
+func funcType(params, results []byte) []byte {
+	out := []byte{0x60}
+	out = append(out, vector(bytesOf(params)...)...)
+	return append(out, vector(bytesOf(results)...)...)
+}
+
+func bytesOf(b []byte) [][]byte {
+	out := make([][]byte, len(b))
+	for i := range b {
+		out[i] = []byte{b[i]}
+	}
+	return out
+}
+
+func concat(parts ...[]byte) []byte {
+	var out []byte
+	for _, part := range parts {
+		out = append(out, part...)
+	}
+	return out
+}
+
+func i32Const(v int64) []byte  { return append([]byte{0x41}, sleb(v)...) }
+func i64Const(v int64) []byte  { return append([]byte{0x42}, sleb(v)...) }
+func call(index uint64) []byte { return append([]byte{0x10}, uleb(index)...) }
+
+func body(locals []byte, code ...[]byte) []byte {
+	content := concat(append([][]byte{locals}, append(code, []byte{0x0b})...)...)
+	return append(uleb(uint64(len(content))), content...)
+}
+
+func importFunc(field string, typeIndex uint64) []byte {
+	return concat(name("mtvm"), name(field), []byte{0x00}, uleb(typeIndex))
+}
+
+func export(field string, kind byte, index uint64) []byte {
+	return concat(name(field), []byte{kind}, uleb(index))
+}
+
+func data(offset int64, content string) []byte {
+	return concat([]byte{0x00}, i32Const(offset), []byte{0x0b}, name(content))
+}
+
+func main() {
+	types := vector(
+		funcType([]byte{i32}, []byte{i32}),                // 0
+		funcType(nil, []byte{i64}),                        // 1
+		funcType([]byte{i32, i32}, []byte{i32}),           // 2
+		funcType(nil, []byte{i32}),                        // 3
+		funcType([]byte{i32, i32, i32, i32}, []byte{i32}), // 4
+		funcType([]byte{i32, i32}, []byte{i64}),           // 5
+	)
+	imports := vector(
+		importFunc("download", 2),      // 0
+		importFunc("save_download", 2), // 1
+		importFunc("symlink", 4),       // 2
+		importFunc("remove_all", 2),    // 3
+		importFunc("readlink", 5),      // 4
+	)
+	functions := vector(uleb(0), uleb(1), uleb(2), uleb(3), uleb(3), uleb(0), uleb(1), uleb(3), uleb(3))
+	memory := vector([]byte{0x00, 0x01})
+	globals := vector(concat([]byte{i32, 0x01}, i32Const(1024), []byte{0x0b}))
+	exports := vector(
+		export("memory", 0x02, 0),
+		export("mtvm_alloc", 0x00, 5),
+		export("mtvm_get_latest_version", 0x00, 6),
+		export("mtvm_download", 0x00, 7),
+		export("mtvm_install", 0x00, 8),
+		export("mtvm_use", 0x00, 9),
+		export("mtvm_remove", 0x00, 10),
+		export("mtvm_get_current_version", 0x00, 11),
+		export("mtvm_api_version", 0x00, 12),
+		export("mtvm_verify", 0x00, 13),
+	)
+	noLocals := vector()
+	code := vector(
+		// mtvm_alloc
+		body(noLocals, []byte{0x23, 0x00, 0x23, 0x00, 0x20, 0x00, 0x6a, 0x24, 0x00}),
+		// mtvm_get_latest_version
+		body(noLocals, i64Const(5)),
+		// mtvm_download
+		body(noLocals, i32Const(16), i32Const(21), call(0)),
+		// mtvm_install
+		body(noLocals, i32Const(48), i32Const(12), call(1)),
+		// mtvm_use
+		body(noLocals, i32Const(48), i32Const(12), i32Const(64), i32Const(9), call(2)),
+		// mtvm_remove
+		body(noLocals,
+			[]byte{0x20, 0x00, 0x04, 0x40},
+			i32Const(64), i32Const(9), call(3),
+			[]byte{0x04, 0x40}, i32Const(1), []byte{0x0f, 0x0b},
+			[]byte{0x0b},
+			i32Const(48), i32Const(7), call(3)),
+		// mtvm_get_current_version
+		body(vector(concat(uleb(1), []byte{i64})),
+			i32Const(64), i32Const(9), call(4),
+			[]byte{0x22, 0x00, 0x50, 0x04, 0x40}, i64Const(0), []byte{0x0f, 0x0b},
+			[]byte{0x20, 0x00}, i64Const(34359738355), []byte{0x7c}),
+		// mtvm_api_version
+		body(noLocals, i32Const(1)),
+		// mtvm_verify
+		body(noLocals, []byte{0x03, 0x40, 0x0c, 0x00, 0x0b}, i32Const(0)),
+	)
+	dataSegments := vector(
+		data(0, "1.2.3"),
+		data(16, "http://mtvm.test/tool"),
+		data(48, "install/tool"),
+		data(64, "path/tool"),
+	)
+	module := concat(
+		[]byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00},
+		section(1, types),
+		section(2, imports),
+		section(3, functions),
+		section(5, memory),
+		section(6, globals),
+		section(7, exports),
+		section(10, code),
+		section(11, dataSegments),
+	)
+	err := os.WriteFile("plugin.wasm", module, 0o644)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,350 @@
+// Package wasm runs plugins compiled to WebAssembly with wazero, so one plugin file works on every platform.
+//
+// Plugins can't touch the file system or the network directly.
+// Instead, they call the host functions in the "mtvm" module, which only accept paths inside the directories of the current call:
+// paths starting with "install/" are inside the install directory and paths starting with "path/" are inside the path directory.
+//
+// A plugin exports its memory as "memory", an allocator as "mtvm_alloc" and one function per plugin method:
+//
+//	mtvm_get_latest_version() i64
+//	mtvm_download(version_ptr i32, version_len i32) i32
+//	mtvm_install() i32
+//	mtvm_use() i32
+//	mtvm_remove(in_use i32) i32
+//	mtvm_get_current_version() i64
+//
+// Plugins can also export mtvm_api_version() i32 to declare the plugin API version they implement,
+// and these functions to support optional capabilities from the api package:
+//
+//	mtvm_list_versions() i64       versions separated by newlines
+//	mtvm_env() i64                 KEY=VALUE lines for the version in "install/"
+//	mtvm_verify() i32              checks the version in "install/"
+//
+// Functions returning i32 return 0 on success. Functions returning i64 return a string as a pointer in the upper
+// 32 bits and a length in the lower 32 bits, and return 0 on failure. Plugins can describe a failure with set_error,
+// host functions that fail describe it themselves.
+package wasm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	pluginapi "github.com/MTVersionManager/mtvm/plugin/api"
+	"github.com/MTVersionManager/mtvmplugin"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+const hostModuleName = "mtvm"
+
+var ErrMissingExport = errors.New("wasm plugin does not export a required function")
+
+// requiredExports are the functions every plugin has to export
+var requiredExports = []string{
+	"mtvm_alloc",
+	"mtvm_get_latest_version",
+	"mtvm_download",
+	"mtvm_install",
+	"mtvm_use",
+	"mtvm_remove",
+	"mtvm_get_current_version",
+}
+
+// Plugin implements mtvmplugin.Plugin for a WebAssembly module
+type Plugin struct {
+	*instance
+	// ctx is the context the calls made through the plugin are bound to, see Bind
+	ctx context.Context
+}
+
+// instance is the module, which is shared by a plugin and the plugins returned by its Bind method
+type instance struct {
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+	module   api.Module
+	client   *http.Client
+	// mu makes sure only one call runs at a time, as the fields below belong to the current call
+	mu sync.Mutex
+	// installRoot and pathRoot are what "install/" and "path/" refer to, empty if the call can't access them
+	installRoot string
+	pathRoot    string
+	progress    chan float64
+	callErr     error
+	// scratchDir holds the file downloaded by the download host function until it is saved or extracted
+	scratchDir     string
+	downloadedFile string
+}
+
+type Option func(*Plugin)
+
+// WithHTTPClient makes the plugin use client for network access instead of http.DefaultClient
+func WithHTTPClient(client *http.Client) Option {
+	return func(p *Plugin) {
+		p.client = client
+	}
+}
+
+// Load compiles and instantiates the WebAssembly module at path
+func Load(path string, opts ...Option) (*Plugin, error) {
+	wasmData, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return New(wasmData, opts...)
+}
+
+// New compiles and instantiates a WebAssembly module.
+// The module is closed if the context of a call is done while it runs, and instantiated again for the next call.
+func New(wasmData []byte, opts ...Option) (*Plugin, error) {
+	ctx := context.Background()
+	p := &Plugin{
+		instance: &instance{
+			runtime: wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().WithCloseOnContextDone(true)),
+			client:  http.DefaultClient,
+		},
+		ctx: ctx,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	// WASI is provided so plugins written in languages that need it work, but without any directories mounted
+	_, err := wasi_snapshot_preview1.Instantiate(ctx, p.runtime)
+	if err != nil {
+		_ = p.Close()
+		return nil, err
+	}
+	_, err = p.hostModule().Instantiate(ctx)
+	if err != nil {
+		_ = p.Close()
+		return nil, err
+	}
+	p.compiled, err = p.runtime.CompileModule(ctx, wasmData)
+	if err != nil {
+		_ = p.Close()
+		return nil, err
+	}
+	for _, name := range requiredExports {
+		if _, ok := p.compiled.ExportedFunctions()[name]; !ok {
+			_ = p.Close()
+			return nil, fmt.Errorf("%w: %v", ErrMissingExport, name)
+		}
+	}
+	err = p.instantiate()
+	if err != nil {
+		_ = p.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+// instantiate instantiates the compiled module, replacing the module that was closed
+func (p *Plugin) instantiate() error {
+	module, err := p.runtime.InstantiateModule(context.Background(), p.compiled, wazero.NewModuleConfig().WithStartFunctions("_initialize").WithStderr(os.Stderr))
+	if err != nil {
+		return err
+	}
+	p.module = module
+	return nil
+}
+
+// Bind returns a plugin for the same module whose calls stop once ctx is done
+func (p *Plugin) Bind(ctx context.Context) mtvmplugin.Plugin {
+	return &Plugin{instance: p.instance, ctx: ctx}
+}
+
+// Close frees the runtime and removes downloaded files that were never installed
+func (p *Plugin) Close() error {
+	if p.scratchDir != "" {
+		_ = os.RemoveAll(p.scratchDir)
+	}
+	return p.runtime.Close(context.Background())
+}
+
+// begin sets up the state for a call, and the returned function has to be called once it is done.
+// The module is instantiated again if the previous call was stopped by its context.
+func (p *Plugin) begin(installRoot, pathRoot string) func() {
+	p.mu.Lock()
+	// If instantiating fails, the call fails because the module is closed
+	if p.module.IsClosed() {
+		_ = p.instantiate()
+	}
+	p.installRoot = installRoot
+	p.pathRoot = pathRoot
+	p.callErr = nil
+	return func() {
+		p.installRoot = ""
+		p.pathRoot = ""
+		p.progress = nil
+		p.mu.Unlock()
+	}
+}
+
+// fail records err as the reason the current call failed, unless a reason was already recorded
+func (p *Plugin) fail(err error) {
+	if p.callErr == nil {
+		p.callErr = err
+	}
+}
+
+// failure returns the error to return for a failed call to function
+func (p *Plugin) failure(function string) error {
+	if p.callErr != nil {
+		return p.callErr
+	}
+	return fmt.Errorf("%v failed", function)
+}
+
+// callStatus calls a function that returns 0 on success
+func (p *Plugin) callStatus(function string, params ...uint64) error {
+	results, err := p.module.ExportedFunction(function).Call(p.ctx, params...)
+	if err != nil {
+		return err
+	}
+	if uint32(results[0]) != 0 {
+		return p.failure(function)
+	}
+	return nil
+}
+
+// callString calls a function that returns a string
+func (p *Plugin) callString(function string, params ...uint64) (string, error) {
+	results, err := p.module.ExportedFunction(function).Call(p.ctx, params...)
+	if err != nil {
+		return "", err
+	}
+	if results[0] == 0 {
+		return "", p.failure(function)
+	}
+	data, ok := p.module.Memory().Read(uint32(results[0]>>32), uint32(results[0]))
+	if !ok {
+		return "", fmt.Errorf("%v returned a string outside of memory", function)
+	}
+	return string(data), nil
+}
+
+// writeString copies s into memory allocated by the plugin
+func (p *Plugin) writeString(s string) (uint32, uint32, error) {
+	results, err := p.module.ExportedFunction("mtvm_alloc").Call(p.ctx, uint64(len(s)))
+	if err != nil {
+		return 0, 0, err
+	}
+	ptr := uint32(results[0])
+	if !p.module.Memory().WriteString(ptr, s) {
+		return 0, 0, errors.New("mtvm_alloc returned memory outside of memory")
+	}
+	return ptr, uint32(len(s)), nil
+}
+
+// APIVersion returns the result of mtvm_api_version, or pluginapi.UndeclaredVersion if the plugin doesn't export it
+func (p *Plugin) APIVersion() int {
+	function := p.module.ExportedFunction("mtvm_api_version")
+	if function == nil {
+		return pluginapi.UndeclaredVersion
+	}
+	defer p.begin("", "")()
+	results, err := function.Call(p.ctx)
+	if err != nil {
+		return pluginapi.UndeclaredVersion
+	}
+	return int(int32(results[0]))
+}
+
+func (p *Plugin) GetLatestVersion() (string, error) {
+	defer p.begin("", "")()
+	return p.callString("mtvm_get_latest_version")
+}
+
+func (p *Plugin) Download(version string, progress chan float64) error {
+	defer p.begin("", "")()
+	p.progress = progress
+	ptr, length, err := p.writeString(version)
+	if err != nil {
+		return err
+	}
+	err = p.callStatus("mtvm_download", uint64(ptr), uint64(length))
+	if err != nil {
+		return err
+	}
+	progress <- 1
+	return nil
+}
+
+func (p *Plugin) Install(installDir string) error {
+	defer p.begin(installDir, "")()
+	err := os.MkdirAll(installDir, 0o755)
+	if err != nil {
+		return err
+	}
+	return p.callStatus("mtvm_install")
+}
+
+func (p *Plugin) Use(installDir string, pathDir string) error {
+	defer p.begin(installDir, pathDir)()
+	return p.callStatus("mtvm_use")
+}
+
+func (p *Plugin) Remove(installDir string, pathDir string, inUse bool) error {
+	defer p.begin(installDir, pathDir)()
+	var inUseParam uint64
+	if inUse {
+		inUseParam = 1
+	}
+	return p.callStatus("mtvm_remove", inUseParam)
+}
+
+func (p *Plugin) GetCurrentVersion(installDir string, pathDir string) (string, error) {
+	defer p.begin(installDir, pathDir)()
+	return p.callString("mtvm_get_current_version")
+}
+
+// Supports reports whether the plugin exports the function needed for a capability
+func (p *Plugin) Supports(capability string) bool {
+	var function string
+	switch capability {
+	case pluginapi.CapabilityVersionLister:
+		function = "mtvm_list_versions"
+	case pluginapi.CapabilityEnvProvider:
+		function = "mtvm_env"
+	case pluginapi.CapabilityVerifier:
+		function = "mtvm_verify"
+	default:
+		return false
+	}
+	return p.module.ExportedFunction(function) != nil
+}
+
+func (p *Plugin) ListVersions() ([]string, error) {
+	defer p.begin("", "")()
+	versions, err := p.callString("mtvm_list_versions")
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(versions), nil
+}
+
+func (p *Plugin) Env(installDir string) (map[string]string, error) {
+	defer p.begin(installDir, "")()
+	lines, err := p.callString("mtvm_env")
+	if err != nil {
+		return nil, err
+	}
+	env := make(map[string]string)
+	for _, line := range strings.Split(lines, "\n") {
+		key, value, ok := strings.Cut(line, "=")
+		if ok {
+			env[key] = value
+		}
+	}
+	return env, nil
+}
+
+func (p *Plugin) Verify(installDir string) error {
+	defer p.begin(installDir, "")()
+	return p.callStatus("mtvm_verify")
+}
@@ -0,0 +1,242 @@
+package wasm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	pluginapi "github.com/MTVersionManager/mtvm/plugin/api"
+)
+
+// roundTripFunc lets tests answer requests made by plugins without a network
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func loadTestPlugin(t *testing.T) *Plugin {
+	client := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.String() != "http://mtvm.test/tool" {
+			return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader(""))}, nil
+		}
+		return &http.Response{
+			StatusCode:    http.StatusOK,
+			ContentLength: 4,
+			Body:          io.NopCloser(strings.NewReader("tool")),
+		}, nil
+	})}
+	plugin, err := Load(filepath.Join("testdata", "plugin.wasm"), WithHTTPClient(client))
+	if err != nil {
+		t.Fatalf("want no error when loading plugin, got %v", err)
+	}
+	t.Cleanup(func() {
+		_ = plugin.Close()
+	})
+	return plugin
+}
+
+func TestGetLatestVersion(t *testing.T) {
+	plugin := loadTestPlugin(t)
+	version, err := plugin.GetLatestVersion()
+	if err != nil {
+		t.Fatalf("want no error, got %v", err)
+	}
+	if version != "1.2.3" {
+		t.Fatalf("want version 1.2.3, got %v", version)
+	}
+}
+
+func TestAPIVersion(t *testing.T) {
+	plugin := loadTestPlugin(t)
+	if got := plugin.APIVersion(); got != 1 {
+		t.Fatalf("want API version 1, got %v", got)
+	}
+}
+
+func TestSupports(t *testing.T) {
+	plugin := loadTestPlugin(t)
+	if plugin.Supports(pluginapi.CapabilityVersionLister) {
+		t.Fatal("want plugin without mtvm_list_versions to not support listing versions")
+	}
+}
+
+func TestCallAfterTimeout(t *testing.T) {
+	plugin := loadTestPlugin(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	// mtvm_verify never returns
+	err := plugin.Bind(ctx).(pluginapi.Verifier).Verify(t.TempDir())
+	if err == nil {
+		t.Fatal("want error once the call timed out, got nil")
+	}
+	version, err := plugin.GetLatestVersion()
+	if err != nil {
+		t.Fatalf("want no error when calling again after a timeout, got %v", err)
+	}
+	if version != "1.2.3" {
+		t.Fatalf("want version 1.2.3, got %v", version)
+	}
+}
+
+func TestInstallUseRemove(t *testing.T) {
+	plugin := loadTestPlugin(t)
+	toolDir := t.TempDir()
+	pathDir := t.TempDir()
+	installDir := filepath.Join(toolDir, "1.0.0")
+
+	progress := make(chan float64)
+	errChannel := make(chan error)
+	go func() {
+		errChannel <- plugin.Download("1.0.0", progress)
+	}()
+	for value := range progress {
+		if value == 1 {
+			break
+		}
+	}
+	if err := <-errChannel; err != nil {
+		t.Fatalf("want no error when downloading, got %v", err)
+	}
+	if err := plugin.Install(installDir); err != nil {
+		t.Fatalf("want no error when installing, got %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(installDir, "tool"))
+	if err != nil {
+		t.Fatalf("want no error when reading installed file, got %v", err)
+	}
+	if string(data) != "tool" {
+		t.Fatalf("want installed file to contain 'tool', got '%v'", string(data))
+	}
+	if err = plugin.Use(installDir, pathDir); err != nil {
+		t.Fatalf("want no error when using, got %v", err)
+	}
+	version, err := plugin.GetCurrentVersion(toolDir, pathDir)
+	if err != nil {
+		t.Fatalf("want no error when getting current version, got %v", err)
+	}
+	if version != "1.0.0" {
+		t.Fatalf("want current version 1.0.0, got '%v'", version)
+	}
+	if err = plugin.Remove(installDir, pathDir, true); err != nil {
+		t.Fatalf("want no error when removing, got %v", err)
+	}
+	if _, err = os.Lstat(filepath.Join(pathDir, "tool")); !os.IsNotExist(err) {
+		t.Fatalf("want link to be removed, got %v (stat)", err)
+	}
+	if _, err = os.Stat(installDir); !os.IsNotExist(err) {
+		t.Fatalf("want install directory to be removed, got %v (stat)", err)
+	}
+}
+
+func TestInstallWithoutDownload(t *testing.T) {
+	plugin := loadTestPlugin(t)
+	err := plugin.Install(t.TempDir())
+	if !errors.Is(err, ErrNoDownload) {
+		t.Fatalf("want ErrNoDownload, got %v", err)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	plugin := &Plugin{instance: &instance{installRoot: "/install/root", pathRoot: "/path/root"}}
+	tests := map[string]struct {
+		pluginPath string
+		want       string
+		wantsError bool
+	}{
+		"install root":      {pluginPath: "install", want: "/install/root"},
+		"install file":      {pluginPath: "install/bin/tool", want: "/install/root/bin/tool"},
+		"path file":         {pluginPath: "path/tool", want: "/path/root/tool"},
+		"parent in install": {pluginPath: "install/bin/../tool", want: "/install/root/tool"},
+		"escape":            {pluginPath: "install/../../etc/passwd", wantsError: true},
+		"absolute":          {pluginPath: "/etc/passwd", wantsError: true},
+		"unknown root":      {pluginPath: "home/user", wantsError: true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := plugin.resolve(tt.pluginPath)
+			if tt.wantsError {
+				if !errors.Is(err, ErrPathNotAllowed) {
+					t.Fatalf("want ErrPathNotAllowed, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("want no error, got %v", err)
+			}
+			if got != filepath.FromSlash(tt.want) {
+				t.Fatalf("want %v, got %v", filepath.FromSlash(tt.want), got)
+			}
+		})
+	}
+}
+
+func TestResolveSymlinks(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	installRoot := filepath.Join(root, "install")
+	pathRoot := filepath.Join(root, "path")
+	outside := filepath.Join(root, "outside")
+	for _, dir := range []string{filepath.Join(installRoot, "bin"), pathRoot, outside} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(installRoot, "bin", "tool"), []byte("tool"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	links := map[string]string{
+		filepath.Join(pathRoot, "tool"):      filepath.Join(installRoot, "bin", "tool"),
+		filepath.Join(installRoot, "escape"): outside,
+		filepath.Join(installRoot, "broken"): filepath.Join(outside, "missing"),
+	}
+	for link, target := range links {
+		if err := os.Symlink(target, link); err != nil {
+			t.Fatal(err)
+		}
+	}
+	plugin := &Plugin{instance: &instance{installRoot: installRoot, pathRoot: pathRoot}}
+	tests := map[string]struct {
+		pluginPath string
+		link       bool
+		want       string
+		wantsError bool
+	}{
+		"link to other root":      {pluginPath: "path/tool", want: filepath.Join(installRoot, "bin", "tool")},
+		"new file":                {pluginPath: "install/lib/tool.so", want: filepath.Join(installRoot, "lib", "tool.so")},
+		"through escaping link":   {pluginPath: "install/escape/.bashrc", wantsError: true},
+		"escaping link":           {pluginPath: "install/escape", wantsError: true},
+		"broken link":             {pluginPath: "install/broken", wantsError: true},
+		"escaping link itself":    {pluginPath: "install/escape", link: true, want: filepath.Join(installRoot, "escape")},
+		"in dir of escaping link": {pluginPath: "install/escape/tool", link: true, wantsError: true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			resolve := plugin.resolve
+			if tt.link {
+				resolve = plugin.resolveLink
+			}
+			got, err := resolve(tt.pluginPath)
+			if tt.wantsError {
+				if !errors.Is(err, ErrPathNotAllowed) {
+					t.Fatalf("want ErrPathNotAllowed, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("want no error, got %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("want %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,10 @@
+package shared
+
+import "errors"
+
+var (
+	ErrPluginNotFound    = errors.New("plugin not found")
+	ErrSymbolNotFound    = errors.New("plugin does not export the " + PluginSymbol + " symbol")
+	ErrInvalidPluginType = errors.New("plugin symbol does not implement mtvmplugin.Plugin")
+	ErrABIMismatch       = errors.New("plugin was built with a different version of mtvm's dependencies")
+)
@@ -0,0 +1,31 @@
+package shared
+
+import (
+	"net/url"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// FileUrl returns the file:// url of a local path, which is made absolute
+func FileUrl(path string) (string, error) {
+	absolute, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	slashed := filepath.ToSlash(absolute)
+	// Windows paths like C:/dir need a slash in front of them to be the path of a url
+	if !strings.HasPrefix(slashed, "/") {
+		slashed = "/" + slashed
+	}
+	return (&url.URL{Scheme: "file", Path: slashed}).String(), nil
+}
+
+// FileUrlPath returns the local path of a file:// url
+func FileUrlPath(fileUrl *url.URL) string {
+	path := fileUrl.Path
+	if runtime.GOOS == "windows" {
+		path = strings.TrimPrefix(path, "/")
+	}
+	return filepath.FromSlash(path)
+}
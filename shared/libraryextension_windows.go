@@ -3,3 +3,5 @@
 package shared
 
 const LibraryExtension = "dll"
+
+const ExecutableExtension = ".exe"
@@ -0,0 +1,47 @@
+package shared
+
+import (
+	"fmt"
+	"os"
+	"plugin"
+	"strings"
+
+	"github.com/MTVersionManager/mtvmplugin"
+)
+
+// PluginSymbol is the name of the symbol that native plugins export their mtvmplugin.Plugin as.
+// Native plugins declare their API version by implementing api.Versioned.
+const PluginSymbol = "Plugin"
+
+// loadNativePlugin opens a plugin built with -buildmode=plugin and returns the value of its PluginSymbol
+func loadNativePlugin(pluginPath string) (mtvmplugin.Plugin, error) {
+	_, err := os.Stat(pluginPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrPluginNotFound, pluginPath)
+		}
+		return nil, err
+	}
+	opened, err := plugin.Open(pluginPath)
+	if err != nil {
+		// The plugin package doesn't have a typed error for this, so this is the only way to detect it
+		if strings.Contains(err.Error(), "different version") {
+			return nil, fmt.Errorf("%w: %w", ErrABIMismatch, err)
+		}
+		return nil, err
+	}
+	symbol, err := opened.Lookup(PluginSymbol)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrSymbolNotFound, pluginPath)
+	}
+	// Exported variables are looked up as pointers, so both the value and a pointer to an interface are accepted
+	switch symbol := symbol.(type) {
+	case mtvmplugin.Plugin:
+		return symbol, nil
+	case *mtvmplugin.Plugin:
+		if *symbol != nil {
+			return *symbol, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s is %T", ErrInvalidPluginType, PluginSymbol, symbol)
+}
@@ -0,0 +1,72 @@
+package shared
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/MTVersionManager/mtvm/plugin/manifest"
+	"github.com/MTVersionManager/mtvm/plugin/rpc"
+	"github.com/MTVersionManager/mtvm/plugin/wasm"
+	"github.com/MTVersionManager/mtvmplugin"
+)
+
+const (
+	// PluginTypeNative is a Go plugin built with -buildmode=plugin
+	PluginTypeNative = "native"
+	// PluginTypeRPC is an executable that speaks the protocol implemented in the rpc package
+	PluginTypeRPC = "rpc"
+	// PluginTypeManifest is a JSON manifest that is handled by the manifest package
+	PluginTypeManifest = "manifest"
+	// PluginTypeWasm is a WebAssembly module that is run by the wasm package
+	PluginTypeWasm = "wasm"
+)
+
+// PluginTypes lists every plugin type in the order LoadPlugin looks for them
+var PluginTypes = []string{PluginTypeNative, PluginTypeRPC, PluginTypeWasm, PluginTypeManifest}
+
+// PluginFile returns the path a plugin of the given type is installed to.
+// An empty plugin type is treated as PluginTypeNative.
+func PluginFile(pluginName, pluginType string) string {
+	switch pluginType {
+	case PluginTypeRPC:
+		return filepath.Join(Configuration.PluginDir, pluginName+ExecutableExtension)
+	case PluginTypeManifest:
+		return filepath.Join(Configuration.PluginDir, pluginName+".json")
+	case PluginTypeWasm:
+		return filepath.Join(Configuration.PluginDir, pluginName+".wasm")
+	default:
+		return filepath.Join(Configuration.PluginDir, pluginName+"."+LibraryExtension)
+	}
+}
+
+// InstalledPluginType returns the type of the plugin file installed for a plugin.
+// Returns an error wrapping ErrPluginNotFound if there is no plugin file.
+func InstalledPluginType(pluginName string) (string, error) {
+	for _, pluginType := range PluginTypes {
+		info, err := os.Stat(PluginFile(pluginName, pluginType))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return "", err
+		}
+		if info.Mode().IsRegular() {
+			return pluginType, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrPluginNotFound, pluginName)
+}
+
+func loadPluginFile(pluginPath, pluginType string) (mtvmplugin.Plugin, error) {
+	switch pluginType {
+	case PluginTypeRPC:
+		return rpc.Start(pluginPath)
+	case PluginTypeManifest:
+		return manifest.Load(pluginPath)
+	case PluginTypeWasm:
+		return wasm.Load(pluginPath)
+	default:
+		return loadNativePlugin(pluginPath)
+	}
+}
@@ -2,23 +2,32 @@ package shared
 
 import (
 	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
-	// "strings"
+	"sync"
 
-	// "github.com/MTVersionManager/goplugin"
+	"github.com/MTVersionManager/mtvm/plugin/api"
+	"github.com/MTVersionManager/mtvm/plugin/builtin"
+	"github.com/MTVersionManager/mtvm/plugin/call"
 	"github.com/MTVersionManager/mtvmplugin"
 	"github.com/charmbracelet/lipgloss"
 
 	"github.com/MTVersionManager/mtvm/config"
 )
 
+// Version is the version of mtvm, which is checked against the versions plugins say they work with
+var Version = "0.1.0"
+
 var Configuration config.Config
 
 type SuccessMsg string
 
 var CheckMark = lipgloss.NewStyle().Foreground(lipgloss.Color("2")).SetString("✓").String()
 
+var CrossMark = lipgloss.NewStyle().Foreground(lipgloss.Color("1")).SetString("✗").String()
+
 func IsVersionInstalled(tool, version string) (bool, error) {
 	_, err := os.Stat(filepath.Join(Configuration.InstallDir, tool, version))
 	if err != nil {
@@ -30,12 +39,70 @@ func IsVersionInstalled(tool, version string) (bool, error) {
 	return true, nil
 }
 
+// CallPlugin wraps a plugin so its calls run under the timeouts from the configuration
+func CallPlugin(plugin mtvmplugin.Plugin) *call.Plugin {
+	return call.Wrap(plugin, call.WithTimeout(Configuration.PluginTimeout), call.WithDownloadTimeout(Configuration.PluginDownloadTimeout))
+}
+
+var (
+	loadedPlugins   = make(map[string]mtvmplugin.Plugin)
+	loadedPluginsMu sync.Mutex
+)
+
+// LoadPlugin loads the plugin for a tool from the plugin directory,
+// using the transport that matches the type of the installed plugin file.
+// If no plugin is installed for the tool, the built-in plugin with its name is used, so installed plugins override built-in ones.
+// Plugins implementing an API version this version of mtvm can't handle are refused with an *api.IncompatibleError.
+// Plugins are only loaded once per process, later calls return the same instance.
+// Returns an error wrapping ErrPluginNotFound if the plugin is neither installed nor built in.
 func LoadPlugin(tool string) (mtvmplugin.Plugin, error) {
-	// var plugin mtvmplugin.Plugin
-	// if strings.ToLower(tool) == "go" {
-	//	plugin = &goplugin.Plugin{}
-	// } else {
-	return nil, errors.New("plugin support is not yet implemented")
-	// }
-	// return plugin, nil
+	loadedPluginsMu.Lock()
+	defer loadedPluginsMu.Unlock()
+	if loaded, ok := loadedPlugins[tool]; ok {
+		return loaded, nil
+	}
+	var loaded mtvmplugin.Plugin
+	pluginType, err := InstalledPluginType(tool)
+	if errors.Is(err, ErrPluginNotFound) {
+		var ok bool
+		loaded, ok = builtin.Lookup(tool)
+		if !ok {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	} else {
+		loaded, err = loadPluginFile(PluginFile(tool, pluginType), pluginType)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = api.Check(api.VersionOf(loaded))
+	if err != nil {
+		if closer, ok := loaded.(io.Closer); ok {
+			_ = closer.Close()
+		}
+		return nil, fmt.Errorf("%v: %w", tool, err)
+	}
+	loadedPlugins[tool] = loaded
+	return loaded, nil
+}
+
+// CheckPluginFile loads the installed plugin file of the given type without keeping it loaded and returns the API version it implements.
+// It returns an *api.IncompatibleError if this version of mtvm can't handle that version.
+// Plugin install uses it to refuse plugins before they are used, like LoadPlugin does.
+func CheckPluginFile(pluginName, pluginType string) (int, error) {
+	loaded, err := loadPluginFile(PluginFile(pluginName, pluginType), pluginType)
+	if err != nil {
+		return 0, err
+	}
+	if closer, ok := loaded.(io.Closer); ok {
+		defer closer.Close()
+	}
+	version := api.VersionOf(loaded)
+	err = api.Check(version)
+	if err != nil {
+		return version, fmt.Errorf("%v: %w", pluginName, err)
+	}
+	return version, nil
 }
@@ -0,0 +1,78 @@
+package shared
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MTVersionManager/mtvm/plugin/builtin"
+	"github.com/MTVersionManager/mtvm/plugin/manifest"
+	"github.com/MTVersionManager/mtvmplugin"
+)
+
+func TestLoadPluginNotInstalled(t *testing.T) {
+	Configuration.PluginDir = t.TempDir()
+	_, err := LoadPlugin("loremIpsum")
+	if err == nil {
+		t.Fatal("want error, got nil")
+	}
+	if !errors.Is(err, ErrPluginNotFound) {
+		t.Fatalf("want error containing ErrPluginNotFound, got %v", err)
+	}
+}
+
+func TestLoadPluginInvalidFile(t *testing.T) {
+	Configuration.PluginDir = t.TempDir()
+	err := os.WriteFile(filepath.Join(Configuration.PluginDir, "loremIpsum."+LibraryExtension), []byte("not a plugin"), 0o666)
+	if err != nil {
+		t.Fatalf("want no error when writing plugin file, got %v", err)
+	}
+	_, err = LoadPlugin("loremIpsum")
+	if err == nil {
+		t.Fatal("want error, got nil")
+	}
+	if errors.Is(err, ErrPluginNotFound) {
+		t.Fatalf("want error not containing ErrPluginNotFound, got %v", err)
+	}
+}
+
+func TestLoadPluginBuiltin(t *testing.T) {
+	Configuration.PluginDir = t.TempDir()
+	want := manifest.New(manifest.Manifest{Name: "loremIpsumBuiltin"})
+	builtin.Register("loremIpsumBuiltin", func() mtvmplugin.Plugin { return want })
+	loaded, err := LoadPlugin("loremIpsumBuiltin")
+	if err != nil {
+		t.Fatalf("want no error, got %v", err)
+	}
+	if loaded != want {
+		t.Fatalf("want built-in plugin, got %v", loaded)
+	}
+}
+
+func TestLoadPluginInstalledOverridesBuiltin(t *testing.T) {
+	Configuration.PluginDir = t.TempDir()
+	builtin.Register("loremIpsumOverridden", func() mtvmplugin.Plugin { return manifest.New(manifest.Manifest{}) })
+	err := os.WriteFile(PluginFile("loremIpsumOverridden", PluginTypeNative), []byte("not a plugin"), 0o666)
+	if err != nil {
+		t.Fatalf("want no error when writing plugin file, got %v", err)
+	}
+	_, err = LoadPlugin("loremIpsumOverridden")
+	if err == nil {
+		t.Fatal("want error from loading the installed plugin file, got nil")
+	}
+}
+
+func TestCheckPluginFileInvalidFile(t *testing.T) {
+	Configuration.PluginDir = t.TempDir()
+	err := os.WriteFile(PluginFile("loremIpsum", PluginTypeWasm), []byte("not a plugin"), 0o666)
+	if err != nil {
+		t.Fatalf("want no error when writing plugin file, got %v", err)
+	}
+	if _, err = CheckPluginFile("loremIpsum", PluginTypeWasm); err == nil {
+		t.Fatal("want error, got nil")
+	}
+	if _, ok := loadedPlugins["loremIpsum"]; ok {
+		t.Fatal("want the plugin not to be kept loaded")
+	}
+}